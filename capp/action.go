@@ -13,4 +13,7 @@ const (
 	SET_OF      = Action(5) // MATCH/MASK: Enable select bits if matching.
 	WRITE_FIRST = Action(6) // VALUE/MASK: Write to first tagged entry
 	WRITE_LIST  = Action(7) // VALUE/MASK: Modify all tagged cells
+
+	CARE_FIRST = Action(8) // VALUE/MASK: Write care bits of first tagged entry
+	CARE_LIST  = Action(9) // VALUE/MASK: Write care bits of all tagged cells
 )
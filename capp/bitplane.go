@@ -0,0 +1,457 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"fmt"
+	"iter"
+	"log"
+	"math/bits"
+)
+
+// BitPlane is a CAPP that stores the set, tag and data bits of its cells
+// as bit-plane vectors, 64 cells to a word. Actions are evaluated a word
+// of cells at a time, with the same results as a Capp.
+type BitPlane struct {
+	Verbose     bool
+	BitsFlipped int
+	SetsSwapped bool
+
+	size     uint         // Number of cells.
+	set      [2][]uint64  // SET bit planes, and swapped SET bit planes.
+	tag      []uint64     // TAG bit plane.
+	data     [32][]uint64 // Data bit planes, LSB first.
+	dontcare [32][]uint64 // Don't care bit planes, LSB first.
+	ternary  bool         // Set when any don't care bit may be set.
+	valid    []uint64     // Cells that exist.
+	changed  []uint64     // Cells changed by the last action.
+	count    uint         // Number of active (selected & tagged) cells.
+	first    int          // Index of the first active cell, or -1.
+}
+
+// NewBitPlane creates a new bit-plane CAPP.
+func NewBitPlane(count uint) (bp *BitPlane) {
+	bp = &BitPlane{}
+	bp.resize(count)
+	bp.Reset()
+
+	return
+}
+
+// resize allocates the bit planes for a number of cells.
+func (bp *BitPlane) resize(count uint) {
+	words := int((count + 63) / 64)
+
+	bp.size = count
+	for n := range bp.set {
+		bp.set[n] = make([]uint64, words)
+	}
+	bp.tag = make([]uint64, words)
+	for b := range bp.data {
+		bp.data[b] = make([]uint64, words)
+		bp.dontcare[b] = make([]uint64, words)
+	}
+	bp.ternary = false
+	bp.changed = make([]uint64, words)
+	bp.valid = make([]uint64, words)
+	for w := range bp.valid {
+		bp.valid[w] = ^uint64(0)
+	}
+	if count%64 != 0 {
+		bp.valid[words-1] = (uint64(1) << (count % 64)) - 1
+	}
+	bp.first = -1
+	bp.count = 0
+}
+
+// Reset fills the memory with 0xffffffff, and tags all cells.
+func (bp *BitPlane) Reset() {
+	for b := range bp.data {
+		copy(bp.data[b], bp.valid)
+		clear(bp.dontcare[b])
+	}
+	bp.ternary = false
+
+	// Put all data into the set.
+	bp.Action(SET_OF, 0xffffffff, 0xffffffff)
+	bp.Action(LIST_ALL, 0, 0)
+
+	bp.BitsFlipped = 0
+}
+
+// Import an external set of cells.
+func (bp *BitPlane) Import(cells []Cell) {
+	bp.resize(uint(len(cells)))
+	for n, cell := range cells {
+		w, bit := n/64, uint64(1)<<(n%64)
+		for s := range bp.set {
+			if cell.Set[s] {
+				bp.set[s][w] |= bit
+			}
+		}
+		if cell.Tag {
+			bp.tag[w] |= bit
+		}
+		for b := range bp.data {
+			if (cell.Data>>b)&1 != 0 {
+				bp.data[b][w] |= bit
+			}
+			if (cell.DontCare>>b)&1 != 0 {
+				bp.dontcare[b][w] |= bit
+				bp.ternary = true
+			}
+		}
+	}
+	bp.SetsSwapped = false
+	bp.update()
+	bp.BitsFlipped = 0
+}
+
+// Export a copy of the cells, with the current set in Set[0].
+func (bp *BitPlane) Export() (cells []Cell) {
+	cells = make([]Cell, bp.size)
+	for n := range cells {
+		cells[n] = bp.At(uint(n))
+	}
+	return
+}
+
+// At returns a copy of a cell, with the current set in Set[0].
+func (bp *BitPlane) At(index uint) (cell Cell) {
+	set := bp.current()
+	w, bit := index/64, uint64(1)<<(index%64)
+
+	cell.Set[0] = bp.set[set][w]&bit != 0
+	cell.Set[1] = bp.set[set^1][w]&bit != 0
+	cell.Tag = bp.tag[w]&bit != 0
+	for b := range bp.data {
+		if bp.data[b][w]&bit != 0 {
+			cell.Data |= 1 << b
+		}
+		if bp.dontcare[b][w]&bit != 0 {
+			cell.DontCare |= 1 << b
+		}
+	}
+	return
+}
+
+// Changed yields the index of each cell changed by the last action or
+// update.
+func (bp *BitPlane) Changed(yield func(index uint) bool) {
+	for w, changed := range bp.changed {
+		for changed != 0 {
+			shift := bits.TrailingZeros64(changed)
+			changed &= changed - 1
+			if !yield(uint(w*64 + shift)) {
+				return
+			}
+		}
+	}
+}
+
+// Flipped returns the bits flipped since the last ClearFlipped.
+func (bp *BitPlane) Flipped() int {
+	return bp.BitsFlipped
+}
+
+// ClearFlipped zeros the bits flipped counter.
+func (bp *BitPlane) ClearFlipped() {
+	bp.BitsFlipped = 0
+}
+
+// AddFlipped adds to the bits flipped counter.
+func (bp *BitPlane) AddFlipped(bits int) {
+	bp.BitsFlipped += bits
+}
+
+// SetVerbose enables verbose logging of actions.
+func (bp *BitPlane) SetVerbose(verbose bool) {
+	bp.Verbose = verbose
+}
+
+// cell gets the data of a cell.
+func (bp *BitPlane) cell(index int) (value uint32) {
+	w, shift := index/64, index%64
+	for b := range bp.data {
+		value |= uint32((bp.data[b][w]>>shift)&1) << b
+	}
+	return
+}
+
+// First gets the data of the first tagged item.
+func (bp *BitPlane) First() (value uint32) {
+	if bp.first >= 0 {
+		value = bp.cell(bp.first)
+	}
+	return
+}
+
+// Count of the number of active (selected & tagged) cells.
+func (bp *BitPlane) Count() (count uint) {
+	count = bp.count
+	return
+}
+
+// Size is the number of cells in the memory.
+func (bp *BitPlane) Size() (size uint) {
+	size = bp.size
+	return
+}
+
+// active returns the active (selected & tagged) cells of a word.
+func (bp *BitPlane) active(w int) uint64 {
+	return bp.set[bp.current()][w] & bp.tag[w]
+}
+
+// indexes returns the iterator over the indexes of the active cells,
+// starting from a cell.
+func (bp *BitPlane) indexes(from int) iter.Seq[int] {
+	return func(yield func(index int) bool) {
+		if from < 0 {
+			return
+		}
+		for w := from / 64; w < len(bp.tag); w++ {
+			active := bp.active(w)
+			if w == from/64 {
+				active &= ^uint64(0) << (from % 64)
+			}
+			for active != 0 {
+				shift := bits.TrailingZeros64(active)
+				if !yield(w*64 + shift) {
+					return
+				}
+				active &= active - 1
+			}
+		}
+	}
+}
+
+// List returns the iterator for the list.
+func (bp *BitPlane) List(yield func(data uint32) bool) {
+	for index := range bp.indexes(bp.first) {
+		if !yield(bp.cell(index)) {
+			break
+		}
+	}
+}
+
+// current returns the index of the active set.
+func (bp *BitPlane) current() int {
+	if bp.SetsSwapped {
+		return 1
+	}
+	return 0
+}
+
+// update recomputes the first active cell and the active cell count.
+func (bp *BitPlane) update() {
+	set := bp.set[bp.current()]
+
+	bp.first = -1
+	bp.count = 0
+	for w := range bp.tag {
+		active := set[w] & bp.tag[w]
+		if active != 0 && bp.first < 0 {
+			bp.first = w*64 + bits.TrailingZeros64(active)
+		}
+		bp.count += uint(bits.OnesCount64(active))
+	}
+}
+
+// match returns the cells of a word where the bits set in mask match
+// the bits in match, ignoring the bits the cells do not care about.
+func (bp *BitPlane) match(w int, match uint32, mask uint32) (eq uint64) {
+	eq = bp.valid[w]
+	for mask != 0 {
+		b := bits.TrailingZeros32(mask)
+		plane := bp.data[b][w]
+		if (match>>b)&1 == 0 {
+			plane = ^plane
+		}
+		if bp.ternary {
+			plane |= bp.dontcare[b][w]
+		}
+		eq &= plane
+		mask &= mask - 1
+	}
+	return
+}
+
+// write updates the bits set in mask of the planes with the bits in
+// match, for the cells of a word. Returns the cells that were changed.
+func (bp *BitPlane) write(planes *[32][]uint64, w int, cells uint64, match uint32, mask uint32) (changed uint64) {
+	for mask != 0 {
+		b := bits.TrailingZeros32(mask)
+		old := planes[b][w]
+		var value uint64
+		if (match>>b)&1 != 0 {
+			value = old | cells
+		} else {
+			value = old &^ cells
+		}
+		planes[b][w] = value
+		bp.BitsFlipped += bits.OnesCount64(old ^ value)
+		changed |= old ^ value
+		mask &= mask - 1
+	}
+	return
+}
+
+// evaluateAll updates the SET and TAG planes of every word, and then
+// the active list.
+func (bp *BitPlane) evaluateAll(eval func(w int, set uint64, tag uint64) (new_set uint64, new_tag uint64)) {
+	set := bp.set[bp.current()]
+	for w := range bp.tag {
+		new_set, new_tag := eval(w, set[w], bp.tag[w])
+		changed := (set[w] ^ new_set) | (bp.tag[w] ^ new_tag)
+		bp.BitsFlipped += bits.OnesCount64(set[w]^new_set) + bits.OnesCount64(bp.tag[w]^new_tag)
+		bp.changed[w] = changed
+		set[w] = new_set
+		bp.tag[w] = new_tag
+	}
+
+	bp.update()
+}
+
+// Update replaces the data of each active cell with the data returned
+// by update, and untags the cell unless keep is set.
+func (bp *BitPlane) Update(update func(data uint32) (new_data uint32, keep bool)) {
+	for w := range bp.tag {
+		active := bp.active(w)
+		bp.changed[w] = 0
+		for active != 0 {
+			shift := bits.TrailingZeros64(active)
+			cell := uint64(1) << shift
+			active &^= cell
+
+			data := bp.cell(w*64 + shift)
+			new_data, keep := update(data)
+			for flip := data ^ new_data; flip != 0; flip &= flip - 1 {
+				bp.data[bits.TrailingZeros32(flip)][w] ^= cell
+			}
+			bp.BitsFlipped += bits.OnesCount32(data ^ new_data)
+			if !keep {
+				bp.tag[w] &^= cell
+				bp.BitsFlipped++
+			}
+			if data != new_data || !keep {
+				bp.changed[w] |= cell
+			}
+		}
+	}
+
+	bp.update()
+}
+
+// Action performs a Capp action on the memory.
+func (bp *BitPlane) Action(action Action, match uint32, mask uint32) {
+	if bp.Verbose {
+		log.Printf("%-16v match:0x%08x mask:0x%08x\n", action, match, mask)
+	}
+
+	switch action {
+	case SET_SWAP:
+		bp.SetsSwapped = !bp.SetsSwapped
+		bp.evaluateAll(func(w int, set uint64, tag uint64) (uint64, uint64) {
+			return set, tag
+		})
+	case SET_OF:
+		// Select only cells where bits set in mask match bits in word.
+		bp.evaluateAll(func(w int, set uint64, tag uint64) (uint64, uint64) {
+			return bp.match(w, match, mask), tag
+		})
+		// Tag manipulation operations.
+	case LIST_ALL:
+		bp.evaluateAll(func(w int, set uint64, tag uint64) (uint64, uint64) {
+			return set, tag | set
+		})
+	case LIST_NEXT:
+		clear(bp.changed)
+		if bp.first >= 0 {
+			w := bp.first / 64
+			bp.tag[w] &^= uint64(1) << (bp.first % 64)
+			bp.count -= 1
+			bp.BitsFlipped++
+			bp.changed[w] = uint64(1) << (bp.first % 64)
+			next := -1
+			for index := range bp.indexes(bp.first) {
+				next = index
+				break
+			}
+			bp.first = next
+		}
+	case LIST_NOT:
+		bp.evaluateAll(func(w int, set uint64, tag uint64) (uint64, uint64) {
+			return set, tag ^ set
+		})
+	case LIST_ONLY:
+		// Keep only tagged cells where bits set in mask match bits in word.
+		bp.evaluateAll(func(w int, set uint64, tag uint64) (uint64, uint64) {
+			selected := set & tag
+			if selected == 0 {
+				return set, tag
+			}
+			return set, tag &^ (selected &^ bp.match(w, match, mask))
+		})
+	case WRITE_LIST:
+		// Update the bits set in mask with the bits in match.
+		clear(bp.changed)
+		for w := range bp.tag {
+			active := bp.active(w)
+			if active == 0 {
+				continue
+			}
+			bp.changed[w] = bp.write(&bp.data, w, active, match, mask)
+		}
+	case CARE_LIST:
+		// Update the care bits set in mask with the bits in match.
+		bp.ternary = true
+		clear(bp.changed)
+		for w := range bp.tag {
+			active := bp.active(w)
+			if active == 0 {
+				continue
+			}
+			bp.changed[w] = bp.write(&bp.dontcare, w, active, ^match, mask)
+		}
+	case WRITE_FIRST:
+		clear(bp.changed)
+		if bp.first >= 0 {
+			w := bp.first / 64
+			cell := uint64(1) << (bp.first % 64)
+			// Update the bits set in mask with the bits in match.
+			bp.changed[w] = bp.write(&bp.data, w, cell, match, mask)
+		}
+	case CARE_FIRST:
+		clear(bp.changed)
+		if bp.first >= 0 {
+			bp.ternary = true
+			w := bp.first / 64
+			cell := uint64(1) << (bp.first % 64)
+			// Update the care bits set in mask with the bits in match.
+			bp.changed[w] = bp.write(&bp.dontcare, w, cell, ^match, mask)
+		}
+	}
+
+	if bp.Verbose {
+		n := 0
+		for index := range bp.indexes(bp.first) {
+			var context string
+			if n == 0 {
+				context = "first   "
+			} else {
+				context = fmt.Sprintf("next[%2d]", n-1)
+			}
+			n += 1
+			changed := " "
+			if (bp.changed[index/64]>>(index%64))&1 != 0 {
+				changed = "*"
+			}
+			log.Printf("%v %v0x%04x", context, changed, bp.cell(index))
+			if n >= 8 {
+				log.Printf(" ... (%d)", bp.Count())
+				break
+			}
+		}
+	}
+}
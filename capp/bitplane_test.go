@@ -0,0 +1,35 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"testing"
+)
+
+func benchmarkMemory(b *testing.B, cp Memory) {
+	for b.Loop() {
+		cp.Action(SET_SWAP, 0, 0)
+		cp.Action(SET_OF, 0x1234, 0xffff)
+		cp.Action(LIST_ALL, 0, 0)
+		cp.Action(LIST_NEXT, 0, 0)
+		cp.Action(SET_SWAP, 0, 0)
+		cp.Action(LIST_ONLY, 0x4, 0x4)
+		cp.Action(WRITE_LIST, 0x8, 0x8)
+	}
+}
+
+func BenchmarkCapp(b *testing.B) {
+	benchmarkMemory(b, NewCapp(8192))
+}
+
+func BenchmarkBitPlane(b *testing.B) {
+	benchmarkMemory(b, NewBitPlane(8192))
+}
+
+func BenchmarkSharded(b *testing.B) {
+	benchmarkMemory(b, NewSharded(1<<20, 8, func(count uint) Memory { return NewBitPlane(count) }))
+}
+
+func BenchmarkBitPlane_Large(b *testing.B) {
+	benchmarkMemory(b, NewBitPlane(1<<20))
+}
@@ -12,11 +12,27 @@ import (
 
 // Cell is an individual CAPP data cell.
 type Cell struct {
-	Set     [2]bool // SET bit state, and swapped SET bit state.
-	Tag     bool    // TAG bit state.
-	Data    uint32  // Data held by this cell.
-	Next    *Cell   // Pointer to the next cell in the SET.
-	Changed bool    // Set when the cell has been changed by operation.
+	Set      [2]bool // SET bit state, and swapped SET bit state.
+	Tag      bool    // TAG bit state.
+	Data     uint32  // Data held by this cell.
+	DontCare uint32  // Ternary bits ignored by matches; the complement of the care mask.
+	Next     *Cell   // Pointer to the next cell in the SET.
+	Changed  bool    // Set when the cell has been changed by the last action.
+}
+
+// match returns true if the bits set in mask match the bits in match,
+// ignoring the bits the cell does not care about.
+func (cell *Cell) match(match uint32, mask uint32) bool {
+	return (cell.Data^match)&mask&^cell.DontCare == 0
+}
+
+// care updates the care bits set in mask with the bits in match, and
+// returns the number of bits flipped.
+func (cell *Cell) care(match uint32, mask uint32) (flipped int) {
+	old_dontcare := cell.DontCare
+	cell.DontCare = (cell.DontCare &^ mask) | (^match & mask)
+	flipped = bits.OnesCount32(cell.DontCare ^ old_dontcare)
+	return
 }
 
 // Computational Associative Parallel Processor
@@ -45,6 +61,7 @@ func (cp *Capp) Reset() {
 	for n := range cp.Cell {
 		cell := &cp.Cell[n]
 		cell.Data = 0xffffffff
+		cell.DontCare = 0
 	}
 
 	// Put all data into the set.
@@ -68,6 +85,12 @@ func (cp *Capp) Count() (count uint) {
 	return
 }
 
+// Size is the number of cells in the memory.
+func (cp *Capp) Size() (size uint) {
+	size = uint(len(cp.Cell))
+	return
+}
+
 // List returns the iterator for the list.
 func (cp *Capp) List(yield func(data uint32) bool) {
 	for cell := cp.firstCell; cell != nil; cell = cell.Next {
@@ -77,6 +100,26 @@ func (cp *Capp) List(yield func(data uint32) bool) {
 	}
 }
 
+// Flipped returns the bits flipped since the last ClearFlipped.
+func (cp *Capp) Flipped() int {
+	return cp.BitsFlipped
+}
+
+// ClearFlipped zeros the bits flipped counter.
+func (cp *Capp) ClearFlipped() {
+	cp.BitsFlipped = 0
+}
+
+// AddFlipped adds to the bits flipped counter.
+func (cp *Capp) AddFlipped(bits int) {
+	cp.BitsFlipped += bits
+}
+
+// SetVerbose enables verbose logging of actions.
+func (cp *Capp) SetVerbose(verbose bool) {
+	cp.Verbose = verbose
+}
+
 // Randomize the flags and contents.
 func (cp *Capp) Randomize(seed int) {
 	rands := rand.New(rand.NewSource(int64(seed)))
@@ -89,6 +132,34 @@ func (cp *Capp) Randomize(seed int) {
 	}
 }
 
+// clearChanged clears the Changed flag of every cell.
+func (cp *Capp) clearChanged() {
+	for n := range cp.Cell {
+		cp.Cell[n].Changed = false
+	}
+}
+
+// Changed yields the index of each cell changed by the last action or
+// update.
+func (cp *Capp) Changed(yield func(index uint) bool) {
+	for n := range cp.Cell {
+		if cp.Cell[n].Changed && !yield(uint(n)) {
+			return
+		}
+	}
+}
+
+// At returns a copy of a cell, with the current set in Set[0].
+func (cp *Capp) At(index uint) (cell Cell) {
+	cell = cp.Cell[index]
+	if cp.SetsSwapped {
+		cell.Set[0], cell.Set[1] = cell.Set[1], cell.Set[0]
+	}
+	cell.Next = nil
+	cell.Changed = false
+	return
+}
+
 func (cp *Capp) evaluateAll(eval func(cell *Cell)) {
 	var set int = 0
 	if cp.SetsSwapped {
@@ -112,6 +183,7 @@ func (cp *Capp) evaluateAll(eval func(cell *Cell)) {
 			cp.BitsFlipped++
 		}
 		cp.BitsFlipped += bits.OnesCount32(cell.Data ^ old_cell.Data)
+		cp.BitsFlipped += bits.OnesCount32(cell.DontCare ^ old_cell.DontCare)
 		cell.Changed = cp.BitsFlipped != old_flipped
 		if cell.Set[set] && cell.Tag {
 			if current == nil {
@@ -129,10 +201,35 @@ func (cp *Capp) evaluateAll(eval func(cell *Cell)) {
 // Import an external set of cells.
 func (cp *Capp) Import(cells []Cell) {
 	cp.Cell = slices.Clone(cells)
+	cp.SetsSwapped = false
 	cp.evaluateAll(func(_ *Cell) {})
 	cp.BitsFlipped = 0
 }
 
+// Export a copy of the cells, with the current set in Set[0].
+func (cp *Capp) Export() (cells []Cell) {
+	cells = make([]Cell, len(cp.Cell))
+	for n := range cells {
+		cells[n] = cp.At(uint(n))
+	}
+	return
+}
+
+// Update replaces the data of each active cell with the data returned
+// by update, and untags the cell unless keep is set.
+func (cp *Capp) Update(update func(data uint32) (new_data uint32, keep bool)) {
+	var set int = 0
+	if cp.SetsSwapped {
+		set = 1
+	}
+
+	cp.evaluateAll(func(cell *Cell) {
+		if cell.Tag && cell.Set[set] {
+			cell.Data, cell.Tag = update(cell.Data)
+		}
+	})
+}
+
 // Action performs a Capp action on the memory.
 func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 	if cp.Verbose {
@@ -152,7 +249,7 @@ func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 	case SET_OF:
 		// Select only cells where bits set in mask match bits in word.
 		cp.evaluateAll(func(cell *Cell) {
-			cell.Set[set] = (cell.Data & mask) == (match & mask)
+			cell.Set[set] = cell.match(match, mask)
 		})
 		// Tag manipulation operations.
 	case LIST_ALL:
@@ -162,17 +259,13 @@ func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 			}
 		})
 	case LIST_NEXT:
+		cp.clearChanged()
 		if cp.firstCell != nil {
 			cp.firstCell.Tag = false
+			cp.firstCell.Changed = true
 			cp.firstCell = cp.firstCell.Next
 			cp.count -= 1
 			cp.BitsFlipped++
-			if cp.firstCell != nil {
-				cp.firstCell.Changed = true
-				for cell := cp.firstCell.Next; cell != nil; cell = cell.Next {
-					cell.Changed = false
-				}
-			}
 		}
 	case LIST_NOT:
 		cp.evaluateAll(func(cell *Cell) {
@@ -184,11 +277,12 @@ func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 		// Keep only tagged cells where bits set in mask match bits in word.
 		cp.evaluateAll(func(cell *Cell) {
 			if cell.Tag && cell.Set[set] {
-				cell.Tag = (cell.Data & mask) == (match & mask)
+				cell.Tag = cell.match(match, mask)
 			}
 		})
 	case WRITE_LIST:
 		// Update the bits set in mask with the bits in match.
+		cp.clearChanged()
 		for cell := cp.firstCell; cell != nil; cell = cell.Next {
 			old_data := cell.Data
 			cell.Data = (cell.Data & ^mask) | (match & mask)
@@ -197,6 +291,7 @@ func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 			cell.Changed = old_flipped != cp.BitsFlipped
 		}
 	case WRITE_FIRST:
+		cp.clearChanged()
 		if cp.firstCell != nil {
 			cell := cp.firstCell
 			// Update the bits set in mask with the bits in match.
@@ -205,9 +300,23 @@ func (cp *Capp) Action(action Action, match uint32, mask uint32) {
 			old_flipped := cp.BitsFlipped
 			cp.BitsFlipped += bits.OnesCount32(cell.Data ^ old_data)
 			cell.Changed = old_flipped != cp.BitsFlipped
-			for cell := cp.firstCell.Next; cell != nil; cell = cell.Next {
-				cell.Changed = false
-			}
+		}
+	case CARE_LIST:
+		// Update the care bits set in mask with the bits in match.
+		cp.clearChanged()
+		for cell := cp.firstCell; cell != nil; cell = cell.Next {
+			old_flipped := cp.BitsFlipped
+			cp.BitsFlipped += cell.care(match, mask)
+			cell.Changed = old_flipped != cp.BitsFlipped
+		}
+	case CARE_FIRST:
+		cp.clearChanged()
+		if cp.firstCell != nil {
+			cell := cp.firstCell
+			// Update the care bits set in mask with the bits in match.
+			flipped := cell.care(match, mask)
+			cp.BitsFlipped += flipped
+			cell.Changed = flipped != 0
 		}
 	}
 
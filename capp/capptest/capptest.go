@@ -0,0 +1,416 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+// Package capptest implements a conformance suite for implementations
+// of the capp.Memory interface.
+package capptest
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+// NewMemory creates a reset CAPP memory with count cells.
+type NewMemory func(count uint) capp.Memory
+
+// TestMemory runs the conformance suite against a CAPP memory
+// implementation. Every implementation must give the same First, Count,
+// List and bits flipped results as the reference capp.Capp.
+func TestMemory(t *testing.T, newMemory NewMemory) {
+	t.Run("Reset", func(t *testing.T) { testReset(t, newMemory) })
+	t.Run("Actions", func(t *testing.T) { testActions(t, newMemory) })
+	t.Run("List", func(t *testing.T) { testList(t, newMemory) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newMemory) })
+	t.Run("Import", func(t *testing.T) { testImport(t, newMemory) })
+	t.Run("Export", func(t *testing.T) { testExport(t, newMemory) })
+	t.Run("Changed", func(t *testing.T) { testChanged(t, newMemory) })
+	t.Run("SetSwap", func(t *testing.T) { testSetSwap(t, newMemory) })
+	t.Run("Ternary", func(t *testing.T) { testTernary(t, newMemory) })
+	t.Run("BitsFlipped", func(t *testing.T) { testBitsFlipped(t, newMemory) })
+	t.Run("Reference", func(t *testing.T) { testReference(t, newMemory) })
+}
+
+// testReset verifies that a reset memory lists every cell as 0xffffffff.
+func testReset(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(100)
+	assert.Equal(uint(100), cp.Size())
+	assert.Equal(uint(100), cp.Count())
+	assert.Equal(uint32(0xffffffff), cp.First())
+	assert.Equal(0, cp.Flipped())
+
+	cp.Action(capp.WRITE_LIST, 0, 0xffffffff)
+	cp.Action(capp.SET_OF, 0x1, 0x1)
+	assert.Equal(uint(0), cp.Count())
+
+	cp.Reset()
+	assert.Equal(uint(100), cp.Count())
+	assert.Equal(uint32(0xffffffff), cp.First())
+	assert.Equal(0, cp.Flipped())
+}
+
+// testActions verifies each action against a table of expected results.
+func testActions(t *testing.T, newMemory NewMemory) {
+	const size = 128
+	assert := assert.New(t)
+
+	table := [](struct {
+		Action capp.Action
+		Match  uint32
+		Mask   uint32
+		Data   uint32
+		Count  uint
+	}){
+		{Action: capp.WRITE_LIST, Match: 0, Mask: 0xffffffff, Count: size, Data: 0}, // Zero the CAPP
+		{Action: capp.LIST_NOT}, // Remove all tags.
+		{Action: capp.SET_OF, Match: 0xffffffff, Mask: 0xffffffff},                        // Select nothing.
+		{Action: capp.WRITE_FIRST, Match: 0b1101},                                         // No-op
+		{Action: capp.LIST_ALL, Data: 0, Count: 0},                                        // Nothing selected.
+		{Action: capp.SET_OF, Mask: 0b1111, Match: 0, Data: 0, Count: 0},                  // Select all
+		{Action: capp.LIST_ALL, Data: 0, Count: size},                                     // Everything is tagged.
+		{Action: capp.WRITE_LIST, Data: 0b0010, Mask: 0b0011, Match: 0b0010, Count: size}, // Update lower 2 bits to 10
+		{Action: capp.LIST_NEXT, Data: 0b0010, Count: size - 1},                           // Unselect first tag
+		{Action: capp.LIST_ALL, Data: 0b0010, Count: size},                                // Re-tag from selection
+		{Action: capp.LIST_ONLY, Data: 0b0000, Mask: 0b0011, Match: 0b0001, Count: 0},     // Winnow all.
+		{Action: capp.LIST_NOT, Data: 0b0010, Count: size},                                // Re-tag all
+		{Action: capp.WRITE_FIRST, Data: 0b1001, Mask: 0b1111, Match: 0b1001, Count: size},
+		{Action: capp.LIST_NEXT, Data: 0b0010, Count: size - 1},
+		{Action: capp.WRITE_FIRST, Data: 0b1010, Mask: 0b1111, Match: 0b1010, Count: size - 1},
+		{Action: capp.LIST_NOT, Data: 0b1001, Count: 1},
+		{Action: capp.LIST_NOT, Data: 0b1010, Count: size - 1},
+		{Action: capp.LIST_ONLY, Data: 0b1010, Mask: 0b1000, Match: 0b1000, Count: 1},
+	}
+
+	cp := newMemory(size)
+
+	for _, testcase := range table {
+		cp.Action(testcase.Action, testcase.Match, testcase.Mask)
+		assert.Equal(testcase.Data, cp.First(), fmt.Sprintf("%+v", testcase))
+		assert.Equal(testcase.Count, cp.Count(), fmt.Sprintf("%+v", testcase))
+	}
+}
+
+// testList verifies that List yields the active cells in order, and
+// stops when yield returns false.
+func testList(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(10)
+	for i := uint32(0); i < 10; i++ {
+		cp.Action(capp.WRITE_FIRST, i, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, slices.Collect(cp.List))
+
+	collected := []uint32{}
+	for data := range cp.List {
+		collected = append(collected, data)
+		if data == 4 {
+			break
+		}
+	}
+	assert.Equal([]uint32{0, 1, 2, 3, 4}, collected)
+
+	cp.Action(capp.SET_OF, 0xffffffff, 0xffffffff)
+	assert.Empty(slices.Collect(cp.List))
+}
+
+// testUpdate verifies that Update replaces the data of the active cells,
+// and untags the cells that are not kept.
+func testUpdate(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(10)
+	for i := uint32(0); i < 10; i++ {
+		cp.Action(capp.WRITE_FIRST, i, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+	cp.Action(capp.SET_OF, 0, 0x8)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	cp.ClearFlipped()
+
+	cp.Update(func(data uint32) (uint32, bool) {
+		return data + 0x10, data&1 == 0
+	})
+	assert.Equal([]uint32{0x10, 0x12, 0x14, 0x16}, slices.Collect(cp.List))
+	assert.Equal(uint(4), cp.Count())
+	assert.Equal(8+4, cp.Flipped(), "8 data bits, and 4 tag bits")
+
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17}, slices.Collect(cp.List))
+
+	cp.Action(capp.SET_OF, 0, 0)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 8, 9}, slices.Collect(cp.List))
+}
+
+// testImport verifies that Import copies the cells.
+func testImport(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cells := []capp.Cell{
+		{Set: [2]bool{true, false}, Tag: true, Data: 0x1111},
+		{Set: [2]bool{true, false}, Tag: true, Data: 0x2222},
+		{Set: [2]bool{false, false}, Tag: false, Data: 0x3333},
+		{Set: [2]bool{true, true}, Tag: true, Data: 0x4444},
+		{Set: [2]bool{false, true}, Tag: true, Data: 0x5555},
+	}
+
+	cp := newMemory(1)
+	cp.Action(capp.WRITE_LIST, 0, 0xffffffff)
+	cp.Import(cells)
+
+	assert.Equal(uint(5), cp.Size())
+	assert.Equal(uint(3), cp.Count())
+	assert.Equal(uint32(0x1111), cp.First())
+	assert.Equal([]uint32{0x1111, 0x2222, 0x4444}, slices.Collect(cp.List))
+	assert.Equal(0, cp.Flipped())
+
+	cells[0].Data = 0x9999
+	assert.Equal(uint32(0x1111), cp.First(), "Import should copy the cells")
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	assert.Equal([]uint32{0x4444, 0x5555}, slices.Collect(cp.List))
+}
+
+// testExport verifies that Export returns the cells given to Import, and
+// that a swapped memory is exported with the current set in Set[0].
+func testExport(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cells := []capp.Cell{
+		{Set: [2]bool{true, false}, Tag: true, Data: 0x1111},
+		{Set: [2]bool{true, false}, Tag: false, Data: 0x2222, DontCare: 0xf0},
+		{Set: [2]bool{false, false}, Tag: false, Data: 0x3333},
+		{Set: [2]bool{true, true}, Tag: true, Data: 0x4444},
+		{Set: [2]bool{false, true}, Tag: true, Data: 0x5555},
+	}
+
+	cp := newMemory(1)
+	cp.Import(cells)
+	assert.Equal(cells, cp.Export())
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	exported := cp.Export()
+	for n := range cells {
+		assert.Equal(cells[n].Set[1], exported[n].Set[0])
+		assert.Equal(cells[n].Set[0], exported[n].Set[1])
+	}
+
+	restored := newMemory(1)
+	restored.Import(exported)
+	assert.Equal(slices.Collect(cp.List), slices.Collect(restored.List))
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	restored.Action(capp.SET_SWAP, 0, 0)
+	assert.Equal(slices.Collect(cp.List), slices.Collect(restored.List))
+	assert.Equal(cp.Export(), restored.Export())
+}
+
+// testChanged verifies that Changed yields only the cells changed by
+// the last action.
+func testChanged(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(70)
+	for i := range uint32(70) {
+		cp.Action(capp.WRITE_FIRST, i, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+	cp.Action(capp.LIST_ALL, 0, 0)
+
+	cp.Action(capp.LIST_ONLY, 0, 0x1)
+	assert.Equal(35, len(slices.Collect(cp.Changed)))
+
+	cp.Action(capp.WRITE_LIST, 0x40, 0x40)
+	assert.Equal([]uint{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34,
+		36, 38, 40, 42, 44, 46, 48, 50, 52, 54, 56, 58, 60, 62}, slices.Collect(cp.Changed))
+
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	assert.Equal([]uint{0}, slices.Collect(cp.Changed))
+
+	cp.Action(capp.WRITE_FIRST, 0x40, 0x40)
+	assert.Empty(slices.Collect(cp.Changed))
+
+	cp.Action(capp.WRITE_FIRST, 0x100, 0x100)
+	assert.Equal([]uint{2}, slices.Collect(cp.Changed))
+	assert.Equal(capp.Cell{Set: [2]bool{true, false}, Tag: true, Data: 0x142}, cp.At(2))
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	assert.Empty(slices.Collect(cp.Changed))
+	assert.Equal(capp.Cell{Set: [2]bool{false, true}, Tag: true, Data: 0x142}, cp.At(2))
+}
+
+// testSetSwap verifies that SET_SWAP toggles between the set banks.
+func testSetSwap(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(10)
+	for i := uint32(0); i < 10; i++ {
+		cp.Action(capp.WRITE_FIRST, i, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	cp.Action(capp.SET_OF, 0, 0x1)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0, 2, 4, 6, 8}, slices.Collect(cp.List))
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal(uint(10), cp.Count())
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	assert.Equal(uint(5), cp.Count())
+}
+
+// testTernary verifies that SET_OF and LIST_ONLY ignore the bits the
+// cells do not care about, as set by CARE_FIRST and CARE_LIST.
+func testTernary(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(4)
+	for _, value := range []uint32{0x1234, 0x1230, 0x1200, 0x1000} {
+		cp.Action(capp.WRITE_FIRST, value, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+	cp.Action(capp.LIST_ALL, 0, 0)
+	cp.ClearFlipped()
+
+	// Don't care about the low 4 bits of any cell.
+	cp.Action(capp.CARE_LIST, 0, 0xf)
+	assert.Equal(4*4, cp.Flipped())
+
+	cp.Action(capp.SET_OF, 0x1235, 0xffff)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x1234, 0x1230}, slices.Collect(cp.List))
+
+	// Care about the low 4 bits of the first cell again.
+	cp.ClearFlipped()
+	cp.Action(capp.CARE_FIRST, 0xffffffff, 0xf)
+	assert.Equal(4, cp.Flipped())
+	assert.Equal([]uint32{0x1234, 0x1230}, slices.Collect(cp.List))
+
+	cp.Action(capp.LIST_ONLY, 0x1235, 0xffff)
+	assert.Equal([]uint32{0x1230}, slices.Collect(cp.List))
+
+	// Prefixes: 0x12xx and 0x1xxx.
+	cp.Action(capp.SET_OF, 0, 0)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	cp.Action(capp.CARE_LIST, 0xffffffff, 0xffffffff)
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	cp.Action(capp.CARE_FIRST, 0xffffff00, 0xffffffff)
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	cp.Action(capp.CARE_FIRST, 0xfffff000, 0xffffffff)
+
+	cp.Action(capp.SET_OF, 0x12ab, 0xffff)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x1200, 0x1000}, slices.Collect(cp.List))
+
+	cp.Action(capp.SET_OF, 0x1abc, 0xffff)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x1000}, slices.Collect(cp.List))
+
+	// Reset cares about all bits.
+	cp.Reset()
+	cp.Action(capp.SET_OF, 0xfffffff0, 0xffffffff)
+	assert.Equal(uint(0), cp.Count())
+}
+
+// testBitsFlipped verifies the bits flipped accounting of each action.
+func testBitsFlipped(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	cp := newMemory(4)
+	cp.Action(capp.WRITE_LIST, 0, 0xffffffff)
+	assert.Equal(4*32, cp.Flipped(), "Clearing all bits of all cells")
+
+	cp.ClearFlipped()
+	assert.Equal(0, cp.Flipped())
+
+	cp.Action(capp.WRITE_FIRST, 0b1111, 0xffffffff)
+	assert.Equal(4, cp.Flipped(), "Writing 4 bits should flip 4 bits")
+
+	cp.ClearFlipped()
+	cp.Action(capp.WRITE_FIRST, 0b11111111, 0xff)
+	assert.Equal(4, cp.Flipped(), "Flipping 4 more bits in first cell")
+
+	cp.ClearFlipped()
+	cp.Action(capp.LIST_NOT, 0, 0)
+	assert.Equal(4, cp.Flipped(), "Toggling 4 tag bits")
+
+	cp.ClearFlipped()
+	cp.Action(capp.SET_OF, 0b11111111, 0xffffffff)
+	assert.Equal(3, cp.Flipped(), "Changing 3 Set bits (first cell unchanged)")
+
+	cp.ClearFlipped()
+	cp.Action(capp.LIST_ALL, 0, 0)
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	assert.Equal(2, cp.Flipped(), "Tagging, then untagging, the only selected cell")
+
+	cp.ClearFlipped()
+	cp.Action(capp.SET_SWAP, 0, 0)
+	assert.Equal(0, cp.Flipped(), "Swapping sets flips no bits")
+
+	cp.AddFlipped(5)
+	assert.Equal(5, cp.Flipped(), "Adding bits flipped outside of the cells")
+}
+
+// testReference verifies that random sequences of actions give the same
+// results as the reference capp.Capp.
+func testReference(t *testing.T, newMemory NewMemory) {
+	assert := assert.New(t)
+
+	for _, size := range []uint{1, 63, 64, 65, 200} {
+		ref := capp.NewCapp(size)
+		ref.Randomize(int(size))
+		// Keep the data values small, so that matches are likely.
+		for n := range ref.Cell {
+			ref.Cell[n].Data &= 0x1f
+		}
+		ref.Import(ref.Cell)
+
+		cp := newMemory(size)
+		cp.Import(ref.Cell)
+
+		rands := rand.New(rand.NewSource(int64(size)))
+		for step := range 2000 {
+			action := capp.Action(rands.Intn(11))
+			match := rands.Uint32() & 0x3f
+			mask := rands.Uint32() & 0x3f
+			if action == 10 {
+				// Update, instead of an action.
+				update := func(data uint32) (uint32, bool) {
+					return (data + match) & 0x3f, data&mask != 0
+				}
+				ref.Update(update)
+				cp.Update(update)
+			} else {
+				ref.Action(action, match, mask)
+				cp.Action(action, match, mask)
+			}
+
+			where := fmt.Sprintf("size %d, step %d, %v 0x%x 0x%x", size, step, action, match, mask)
+			if !assert.Equal(ref.Count(), cp.Count(), where) ||
+				!assert.Equal(ref.First(), cp.First(), where) ||
+				!assert.Equal(ref.Flipped(), cp.Flipped(), where) ||
+				!assert.Equal(slices.Collect(ref.List), slices.Collect(cp.List), where) ||
+				!assert.Equal(slices.Collect(ref.Changed), slices.Collect(cp.Changed), where) {
+				return
+			}
+		}
+
+		assert.Equal(ref.Export(), cp.Export(), "size %d", size)
+		for index := range size {
+			assert.Equal(ref.At(index), cp.At(index), "size %d, cell %d", size, index)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"errors"
+
+	"github.com/ezrec/ucapp/translate"
+)
+
+var f = translate.From
+
+var (
+	// ErrStateInvalid is returned when a saved CAPP state is inconsistent.
+	ErrStateInvalid = errors.New(f("capp state invalid"))
+
+	// ErrJournalMark is returned when a journal can not be rolled back to a mark.
+	ErrJournalMark = errors.New(f("journal mark unavailable"))
+
+	// ErrWatchMissing is returned when a watchpoint does not exist.
+	ErrWatchMissing = errors.New(f("watchpoint missing"))
+)
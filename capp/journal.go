@@ -0,0 +1,136 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"slices"
+)
+
+// journalEntry is the prior content of a cell changed by an action, or of
+// all of the cells replaced by a Reset or Import, or a SET_SWAP.
+type journalEntry struct {
+	index uint   // Index of the changed cell.
+	cell  Cell   // Prior content of the changed cell.
+	swap  bool   // Set for a SET_SWAP.
+	cells []Cell // Prior content of all of the cells, if not nil.
+}
+
+// Journal is a Memory that records the prior content of the cells changed
+// by each action of another Memory, so that the changes can be rolled
+// back to a mark. Only the cells reported by Changed are recorded, so the
+// cost of an action is proportional to the cells it changed.
+type Journal struct {
+	Memory
+
+	cells []Cell         // Content of the memory, with the current set in Set[0].
+	undo  []journalEntry // Journal entries, oldest first.
+	base  int            // Mark of the oldest journal entry.
+}
+
+// NewJournal creates a journal of the changes to a memory. All changes
+// to the memory must be made through the journal.
+func NewJournal(mem Memory) (jn *Journal) {
+	jn = &Journal{
+		Memory: mem,
+		cells:  mem.Export(),
+	}
+	return
+}
+
+// Mark returns the current position of the journal.
+func (jn *Journal) Mark() int {
+	return jn.base + len(jn.undo)
+}
+
+// Forget discards the journal entries before a mark. The memory can no
+// longer be rolled back past the mark.
+func (jn *Journal) Forget(mark int) {
+	mark = min(max(mark, jn.base), jn.Mark())
+	jn.undo = jn.undo[mark-jn.base:]
+	jn.base = mark
+}
+
+// Rollback undoes all of the changes to the memory since a mark.
+func (jn *Journal) Rollback(mark int) (err error) {
+	if mark < jn.base || mark > jn.Mark() {
+		err = ErrJournalMark
+		return
+	}
+
+	for len(jn.undo) > mark-jn.base {
+		entry := jn.undo[len(jn.undo)-1]
+		jn.undo = jn.undo[:len(jn.undo)-1]
+		switch {
+		case entry.cells != nil:
+			jn.cells = entry.cells
+		case entry.swap:
+			jn.swap()
+		default:
+			jn.cells[entry.index] = entry.cell
+		}
+	}
+
+	jn.Memory.Import(jn.cells)
+	return
+}
+
+// swap exchanges the current and swapped sets of all of the cells.
+func (jn *Journal) swap() {
+	for n := range jn.cells {
+		set := &jn.cells[n].Set
+		set[0], set[1] = set[1], set[0]
+	}
+}
+
+// record appends the prior content of the cells changed by the last
+// action or update.
+func (jn *Journal) record() {
+	for index := range jn.Memory.Changed {
+		jn.undo = append(jn.undo, journalEntry{index: index, cell: jn.cells[index]})
+		jn.cells[index] = jn.Memory.At(index)
+	}
+}
+
+// recordAll appends the prior content of all of the cells.
+func (jn *Journal) recordAll() {
+	jn.undo = append(jn.undo, journalEntry{cells: jn.cells})
+	jn.cells = jn.Memory.Export()
+}
+
+// Action performs a Capp action on the memory.
+func (jn *Journal) Action(action Action, match uint32, mask uint32) {
+	jn.Memory.Action(action, match, mask)
+
+	if action == SET_SWAP {
+		jn.undo = append(jn.undo, journalEntry{swap: true})
+		jn.swap()
+		return
+	}
+
+	jn.record()
+}
+
+// Update replaces the data of each active cell with the data returned
+// by update, and untags the cell unless keep is set.
+func (jn *Journal) Update(update func(data uint32) (new_data uint32, keep bool)) {
+	jn.Memory.Update(update)
+	jn.record()
+}
+
+// Reset fills the memory with 0xffffffff, and tags all cells.
+func (jn *Journal) Reset() {
+	jn.Memory.Reset()
+	jn.recordAll()
+}
+
+// Import replaces the memory with a copy of a set of cells.
+func (jn *Journal) Import(cells []Cell) {
+	jn.Memory.Import(cells)
+	jn.recordAll()
+}
+
+// Export returns a copy of the cells of the memory.
+func (jn *Journal) Export() (cells []Cell) {
+	cells = slices.Clone(jn.cells)
+	return
+}
@@ -0,0 +1,66 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, mem := range []Memory{NewCapp(100), NewBitPlane(100), NewSharded(100, 3, func(count uint) Memory { return NewBitPlane(count) })} {
+		jn := NewJournal(mem)
+
+		rands := rand.New(rand.NewSource(1))
+		var marks []int
+		var exports [][]Cell
+		for step := range 200 {
+			marks = append(marks, jn.Mark())
+			exports = append(exports, mem.Export())
+
+			switch step {
+			case 50:
+				jn.Reset()
+			case 100:
+				jn.Update(func(data uint32) (uint32, bool) { return data ^ 0x5, data&1 == 0 })
+			default:
+				jn.Action(Action(rands.Intn(10)), rands.Uint32()&0xf, rands.Uint32()&0xf)
+			}
+			assert.Equal(mem.Export(), jn.Export(), "step %d", step)
+		}
+
+		for step := len(marks) - 1; step >= 0; step -= 7 {
+			assert.NoError(jn.Rollback(marks[step]))
+			assert.Equal(exports[step], mem.Export(), "step %d", step)
+			assert.Equal(exports[step], jn.Export(), "step %d", step)
+		}
+	}
+}
+
+func TestJournal_Forget(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := NewCapp(8)
+	jn := NewJournal(cp)
+
+	jn.Action(WRITE_FIRST, 1, 0xff)
+	mark := jn.Mark()
+	jn.Action(LIST_NEXT, 0, 0)
+	jn.Action(WRITE_FIRST, 2, 0xff)
+	assert.Equal(3, jn.Mark())
+
+	jn.Forget(mark)
+	assert.ErrorIs(jn.Rollback(0), ErrJournalMark)
+	assert.ErrorIs(jn.Rollback(4), ErrJournalMark)
+
+	assert.NoError(jn.Rollback(mark))
+	assert.Equal(mark, jn.Mark())
+	assert.Equal(uint32(0xffffff01), jn.First())
+	assert.Equal(uint(8), jn.Count())
+	assert.Equal([]uint32{0xffffff01, 0xffffffff, 0xffffffff}, slices.Collect(jn.List)[:3])
+}
@@ -0,0 +1,50 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+// Memory is a CAPP memory model. The CPU depends on this interface, so
+// that alternative CAPP implementations may be selected.
+type Memory interface {
+	// Action performs a CAPP action on the memory.
+	Action(action Action, match uint32, mask uint32)
+	// First gets the data of the first tagged item.
+	First() (value uint32)
+	// Count of the number of active (selected & tagged) cells.
+	Count() (count uint)
+	// Size is the number of cells in the memory.
+	Size() (size uint)
+	// List yields the data of the active cells, in order.
+	List(yield func(data uint32) bool)
+	// Update replaces the data of each active cell with the data returned
+	// by update, and untags the cell unless keep is set. The update function
+	// may be called concurrently.
+	Update(update func(data uint32) (new_data uint32, keep bool))
+	// Reset fills the memory with 0xffffffff, and tags all cells.
+	Reset()
+	// Import replaces the memory with a copy of a set of cells. Set[0] of
+	// each cell is the current set, and Set[1] the swapped set.
+	Import(cells []Cell)
+	// Export returns a copy of the cells of the memory, in the form
+	// accepted by Import.
+	Export() (cells []Cell)
+	// At returns a copy of a cell, with the current set in Set[0].
+	At(index uint) (cell Cell)
+	// Changed yields the index of each cell changed by the last action or
+	// update.
+	Changed(yield func(index uint) bool)
+	// Flipped returns the bits flipped since the last ClearFlipped.
+	Flipped() int
+	// ClearFlipped zeros the bits flipped counter.
+	ClearFlipped()
+	// AddFlipped adds to the bits flipped counter the bits flipped outside
+	// of the cells, such as the per-cell registers of a coprocessor.
+	AddFlipped(bits int)
+	// SetVerbose enables verbose logging of actions.
+	SetVerbose(verbose bool)
+}
+
+var _ Memory = (*Capp)(nil)
+var _ Memory = (*BitPlane)(nil)
+var _ Memory = (*Sharded)(nil)
+var _ Memory = (*Journal)(nil)
+var _ Memory = (*Watcher)(nil)
@@ -0,0 +1,42 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp_test
+
+import (
+	"testing"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/capp/capptest"
+)
+
+func newCapp(count uint) capp.Memory { return capp.NewCapp(count) }
+
+func newBitPlane(count uint) capp.Memory { return capp.NewBitPlane(count) }
+
+func TestMemory_Capp(t *testing.T) {
+	capptest.TestMemory(t, newCapp)
+}
+
+func TestMemory_BitPlane(t *testing.T) {
+	capptest.TestMemory(t, newBitPlane)
+}
+
+func TestMemory_Sharded(t *testing.T) {
+	capptest.TestMemory(t, func(count uint) capp.Memory { return capp.NewSharded(count, 3, newCapp) })
+}
+
+func TestMemory_ShardedBitPlane(t *testing.T) {
+	capptest.TestMemory(t, func(count uint) capp.Memory { return capp.NewSharded(count, 4, newBitPlane) })
+}
+
+func TestMemory_Journal(t *testing.T) {
+	capptest.TestMemory(t, func(count uint) capp.Memory { return capp.NewJournal(newBitPlane(count)) })
+}
+
+func TestMemory_Watcher(t *testing.T) {
+	capptest.TestMemory(t, func(count uint) capp.Memory {
+		wt := capp.NewWatcher(newCapp(count))
+		wt.Watch(capp.Watchpoint{Kind: capp.WATCH_CHANGE, Mask: 0, Bits: 0xffffffff})
+		return wt
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"log"
+	"slices"
+	"sync"
+)
+
+// Sharded is a CAPP partitioned into shards of contiguous cells. Actions
+// that evaluate every cell are run on all of the shards concurrently.
+// The active list is the concatenation of the shard lists in shard order,
+// so First, Count, List and bits flipped are the same as a single memory
+// of the same cells.
+type Sharded struct {
+	Verbose bool
+
+	shards []Memory // Shards, in cell order.
+	acted  []Memory // Shards changed by the last action.
+}
+
+// NewSharded creates a new CAPP of count cells, partitioned into a
+// number of shards created by newShard.
+func NewSharded(count uint, shards uint, newShard func(count uint) Memory) (sh *Sharded) {
+	shards = max(shards, 1)
+
+	sh = &Sharded{
+		shards: make([]Memory, shards),
+	}
+
+	for n, size := range shardSizes(count, shards) {
+		sh.shards[n] = newShard(size)
+	}
+
+	return
+}
+
+// shardSizes splits count cells as evenly as possible over the shards.
+func shardSizes(count uint, shards uint) (sizes []uint) {
+	sizes = make([]uint, shards)
+	for n := range sizes {
+		sizes[n] = count / shards
+		if uint(n) < count%shards {
+			sizes[n]++
+		}
+	}
+	return
+}
+
+// parallel runs a function on every shard concurrently.
+func (sh *Sharded) parallel(do func(shard Memory)) {
+	sh.acted = sh.shards
+
+	if len(sh.shards) == 1 {
+		do(sh.shards[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, shard := range sh.shards {
+		wg.Go(func() { do(shard) })
+	}
+	wg.Wait()
+}
+
+// first returns the first shard with active cells, or nil.
+func (sh *Sharded) first() Memory {
+	for _, shard := range sh.shards {
+		if shard.Count() != 0 {
+			return shard
+		}
+	}
+	return nil
+}
+
+// Reset fills the memory with 0xffffffff, and tags all cells.
+func (sh *Sharded) Reset() {
+	sh.parallel(func(shard Memory) { shard.Reset() })
+}
+
+// Import an external set of cells. The cells are partitioned over the
+// same number of shards.
+func (sh *Sharded) Import(cells []Cell) {
+	sizes := shardSizes(uint(len(cells)), uint(len(sh.shards)))
+
+	from := uint(0)
+	for n, size := range sizes {
+		sh.shards[n].Import(cells[from : from+size])
+		from += size
+	}
+	sh.acted = sh.shards
+}
+
+// Export a copy of the cells of all of the shards, in cell order.
+func (sh *Sharded) Export() (cells []Cell) {
+	for _, shard := range sh.shards {
+		cells = append(cells, shard.Export()...)
+	}
+	return
+}
+
+// At returns a copy of a cell, with the current set in Set[0].
+func (sh *Sharded) At(index uint) (cell Cell) {
+	for _, shard := range sh.shards {
+		if index < shard.Size() {
+			cell = shard.At(index)
+			return
+		}
+		index -= shard.Size()
+	}
+	panic("capp: cell index out of range")
+}
+
+// Changed yields the index of each cell changed by the last action or
+// update.
+func (sh *Sharded) Changed(yield func(index uint) bool) {
+	offset := uint(0)
+	for _, shard := range sh.shards {
+		if slices.Contains(sh.acted, shard) {
+			for index := range shard.Changed {
+				if !yield(offset + index) {
+					return
+				}
+			}
+		}
+		offset += shard.Size()
+	}
+}
+
+// First gets the data of the first tagged item.
+func (sh *Sharded) First() (value uint32) {
+	if shard := sh.first(); shard != nil {
+		value = shard.First()
+	}
+	return
+}
+
+// Count of the number of active (selected & tagged) cells.
+func (sh *Sharded) Count() (count uint) {
+	for _, shard := range sh.shards {
+		count += shard.Count()
+	}
+	return
+}
+
+// Size is the number of cells in the memory.
+func (sh *Sharded) Size() (size uint) {
+	for _, shard := range sh.shards {
+		size += shard.Size()
+	}
+	return
+}
+
+// List returns the iterator for the list.
+func (sh *Sharded) List(yield func(data uint32) bool) {
+	for _, shard := range sh.shards {
+		for data := range shard.List {
+			if !yield(data) {
+				return
+			}
+		}
+	}
+}
+
+// Flipped returns the bits flipped since the last ClearFlipped.
+func (sh *Sharded) Flipped() (flipped int) {
+	for _, shard := range sh.shards {
+		flipped += shard.Flipped()
+	}
+	return
+}
+
+// ClearFlipped zeros the bits flipped counter.
+func (sh *Sharded) ClearFlipped() {
+	for _, shard := range sh.shards {
+		shard.ClearFlipped()
+	}
+}
+
+// AddFlipped adds to the bits flipped counter, of the first shard.
+func (sh *Sharded) AddFlipped(bits int) {
+	sh.shards[0].AddFlipped(bits)
+}
+
+// SetVerbose enables verbose logging of actions.
+func (sh *Sharded) SetVerbose(verbose bool) {
+	sh.Verbose = verbose
+}
+
+// Update replaces the data of each active cell with the data returned
+// by update, and untags the cell unless keep is set. The shards are
+// updated concurrently.
+func (sh *Sharded) Update(update func(data uint32) (new_data uint32, keep bool)) {
+	sh.parallel(func(shard Memory) { shard.Update(update) })
+}
+
+// Action performs a Capp action on the memory.
+func (sh *Sharded) Action(action Action, match uint32, mask uint32) {
+	if sh.Verbose {
+		log.Printf("%-16v match:0x%08x mask:0x%08x\n", action, match, mask)
+	}
+
+	switch action {
+	case LIST_NEXT, WRITE_FIRST, CARE_FIRST:
+		// Only the shard holding the first active cell is changed.
+		sh.acted = nil
+		if shard := sh.first(); shard != nil {
+			shard.Action(action, match, mask)
+			sh.acted = []Memory{shard}
+		}
+	default:
+		sh.parallel(func(shard Memory) { shard.Action(action, match, mask) })
+	}
+
+	if sh.Verbose {
+		n := 0
+		for data := range sh.List {
+			if n == 0 {
+				log.Printf("first    0x%04x", data)
+			} else {
+				log.Printf("next[%2d] 0x%04x", n-1, data)
+			}
+			n += 1
+			if n >= 8 {
+				log.Printf(" ... (%d)", sh.Count())
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+// State is the saved state of a CAPP memory. The SET and TAG bits of the
+// cells are packed as bitmaps, LSB first.
+type State struct {
+	Data     []uint32  `json:"data"`               // Data of each cell.
+	DontCare []uint32  `json:"dontcare,omitempty"` // Don't care bits of each cell, if any are set.
+	Set      [2][]byte `json:"set"`                // Current and swapped SET bitmaps.
+	Tag      []byte    `json:"tag"`                // TAG bitmap.
+}
+
+// SaveState returns the state of a CAPP memory.
+func SaveState(mem Memory) (state *State) {
+	cells := mem.Export()
+	bitmap := func(bit func(cell *Cell) bool) (packed []byte) {
+		packed = make([]byte, (len(cells)+7)/8)
+		for n := range cells {
+			if bit(&cells[n]) {
+				packed[n/8] |= 1 << (n % 8)
+			}
+		}
+		return
+	}
+
+	state = &State{
+		Data: make([]uint32, len(cells)),
+		Set: [2][]byte{
+			bitmap(func(cell *Cell) bool { return cell.Set[0] }),
+			bitmap(func(cell *Cell) bool { return cell.Set[1] }),
+		},
+		Tag: bitmap(func(cell *Cell) bool { return cell.Tag }),
+	}
+
+	for n, cell := range cells {
+		state.Data[n] = cell.Data
+		if cell.DontCare != 0 {
+			if state.DontCare == nil {
+				state.DontCare = make([]uint32, len(cells))
+			}
+			state.DontCare[n] = cell.DontCare
+		}
+	}
+
+	return
+}
+
+// LoadState replaces the content of a CAPP memory with a saved state.
+func LoadState(mem Memory, state *State) (err error) {
+	size := len(state.Data)
+	bytes := (size + 7) / 8
+	if len(state.Set[0]) != bytes || len(state.Set[1]) != bytes || len(state.Tag) != bytes ||
+		(state.DontCare != nil && len(state.DontCare) != size) {
+		err = ErrStateInvalid
+		return
+	}
+
+	bit := func(packed []byte, n int) bool {
+		return packed[n/8]&(1<<(n%8)) != 0
+	}
+
+	cells := make([]Cell, size)
+	for n := range cells {
+		cell := &cells[n]
+		cell.Data = state.Data[n]
+		if state.DontCare != nil {
+			cell.DontCare = state.DontCare[n]
+		}
+		cell.Set[0] = bit(state.Set[0], n)
+		cell.Set[1] = bit(state.Set[1], n)
+		cell.Tag = bit(state.Tag, n)
+	}
+
+	mem.Import(cells)
+	return
+}
@@ -0,0 +1,46 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := NewCapp(70)
+	cp.Randomize(70)
+	cp.Action(CARE_FIRST, 0, 0xff)
+	cp.Action(SET_SWAP, 0, 0)
+	cp.Action(SET_OF, 0, 0x1)
+
+	data, err := json.Marshal(SaveState(cp))
+	assert.NoError(err)
+
+	var state State
+	assert.NoError(json.Unmarshal(data, &state))
+
+	bp := NewBitPlane(1)
+	assert.NoError(LoadState(bp, &state))
+	assert.Equal(cp.Export(), bp.Export())
+	assert.Equal(slices.Collect(cp.List), slices.Collect(bp.List))
+	assert.Equal(0, bp.Flipped())
+
+	state.Tag = state.Tag[1:]
+	assert.ErrorIs(LoadState(bp, &state), ErrStateInvalid)
+}
+
+func TestState_Binary(t *testing.T) {
+	assert := assert.New(t)
+
+	state := SaveState(NewCapp(10))
+	assert.Nil(state.DontCare)
+	assert.Equal([]byte{0xff, 0x03}, state.Tag)
+	assert.Equal([]byte{0xff, 0x03}, state.Set[0])
+	assert.Equal([]byte{0x00, 0x00}, state.Set[1])
+}
@@ -0,0 +1,207 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WatchKind is the type of change a watchpoint triggers on.
+type WatchKind int
+
+//go:generate go tool stringer -linecomment -type=WatchKind
+const (
+	WATCH_APPEAR    = WatchKind(0) // appear
+	WATCH_DISAPPEAR = WatchKind(1) // disappear
+	WATCH_CHANGE    = WatchKind(2) // change
+)
+
+// Watchpoint is a content watchpoint, on the cells whose data bits set
+// in Mask match the bits in Match. WATCH_APPEAR triggers when a cell
+// starts matching, WATCH_DISAPPEAR when a cell stops matching, and
+// WATCH_CHANGE when any of the Bits of a cell that still matches are
+// changed.
+type Watchpoint struct {
+	Kind  WatchKind // Type of change to trigger on.
+	Match uint32    // Bits to match.
+	Mask  uint32    // Bits of the data to compare with Match.
+	Bits  uint32    // WATCH_CHANGE: Data or care bits to watch for changes.
+}
+
+// String returns the watchpoint in the form of the debugger command.
+func (wp Watchpoint) String() string {
+	if wp.Kind == WATCH_CHANGE {
+		return fmt.Sprintf("%v 0x%08x 0x%08x 0x%08x", wp.Kind, wp.Match, wp.Mask, wp.Bits)
+	}
+	return fmt.Sprintf("%v 0x%08x 0x%08x", wp.Kind, wp.Match, wp.Mask)
+}
+
+// triggered returns true if the change of a cell from old to new
+// triggers the watchpoint.
+func (wp *Watchpoint) triggered(old *Cell, new *Cell) bool {
+	was := old.match(wp.Match, wp.Mask)
+	is := new.match(wp.Match, wp.Mask)
+
+	switch wp.Kind {
+	case WATCH_APPEAR:
+		return !was && is
+	case WATCH_DISAPPEAR:
+		return was && !is
+	case WATCH_CHANGE:
+		flipped := (old.Data ^ new.Data) | (old.DontCare ^ new.DontCare)
+		return was && is && flipped&wp.Bits != 0
+	}
+
+	return false
+}
+
+// WatchEvent is a watchpoint triggered by the change of a cell.
+type WatchEvent struct {
+	Id         int        // Identifier of the watchpoint.
+	Watchpoint Watchpoint // Watchpoint triggered.
+	Index      uint       // Index of the changed cell.
+	Old        Cell       // Prior content of the cell.
+	New        Cell       // New content of the cell.
+}
+
+// String returns a description of the event.
+func (ev WatchEvent) String() string {
+	return fmt.Sprintf("%d (%v): cell %d 0x%08x -> 0x%08x",
+		ev.Id, ev.Watchpoint, ev.Index, ev.Old.Data, ev.New.Data)
+}
+
+// watch is an installed watchpoint.
+type watch struct {
+	id         int
+	watchpoint Watchpoint
+}
+
+// Watcher is a Memory that checks a set of content watchpoints after
+// each action of another Memory. Only the cells reported by Changed are
+// checked, so the cost of an action is proportional to the cells it
+// changed.
+//
+// A Reset or Import replaces the memory content without triggering
+// watchpoints.
+type Watcher struct {
+	Memory
+
+	cells   []Cell       // Content of the memory, with the current set in Set[0].
+	watches []watch      // Watchpoints, in identifier order.
+	last    int          // Last watchpoint identifier.
+	events  []WatchEvent // Events since the last call to Events.
+}
+
+// NewWatcher creates a watcher of the changes to a memory. All changes
+// to the memory must be made through the watcher.
+func NewWatcher(mem Memory) (wt *Watcher) {
+	wt = &Watcher{
+		Memory: mem,
+		cells:  mem.Export(),
+	}
+	return
+}
+
+// Watch adds a watchpoint, and returns its identifier.
+func (wt *Watcher) Watch(wp Watchpoint) (id int) {
+	wt.last++
+	id = wt.last
+	wt.watches = append(wt.watches, watch{id: id, watchpoint: wp})
+	return
+}
+
+// Unwatch removes a watchpoint.
+func (wt *Watcher) Unwatch(id int) (err error) {
+	n := slices.IndexFunc(wt.watches, func(w watch) bool { return w.id == id })
+	if n < 0 {
+		err = fmt.Errorf("%w: %d", ErrWatchMissing, id)
+		return
+	}
+
+	wt.watches = slices.Delete(wt.watches, n, n+1)
+	return
+}
+
+// Watchpoints yields the identifier and watchpoint of each watchpoint.
+func (wt *Watcher) Watchpoints(yield func(id int, wp Watchpoint) bool) {
+	for _, w := range wt.watches {
+		if !yield(w.id, w.watchpoint) {
+			return
+		}
+	}
+}
+
+// Events returns, and clears, the events triggered since the last call.
+func (wt *Watcher) Events() (events []WatchEvent) {
+	events = wt.events
+	wt.events = nil
+	return
+}
+
+// Sync re-reads the content of the memory, without triggering
+// watchpoints. Sync must be called if the memory has been changed other
+// than through the watcher.
+func (wt *Watcher) Sync() {
+	wt.cells = wt.Memory.Export()
+}
+
+// check compares the cells changed by the last action or update with
+// their prior content, and records the watchpoints they trigger.
+func (wt *Watcher) check() {
+	for index := range wt.Memory.Changed {
+		old := wt.cells[index]
+		new := wt.Memory.At(index)
+		wt.cells[index] = new
+
+		if old.Data == new.Data && old.DontCare == new.DontCare {
+			continue
+		}
+
+		for _, w := range wt.watches {
+			if w.watchpoint.triggered(&old, &new) {
+				wt.events = append(wt.events, WatchEvent{
+					Id:         w.id,
+					Watchpoint: w.watchpoint,
+					Index:      index,
+					Old:        old,
+					New:        new,
+				})
+			}
+		}
+	}
+}
+
+// Action performs a Capp action on the memory.
+func (wt *Watcher) Action(action Action, match uint32, mask uint32) {
+	wt.Memory.Action(action, match, mask)
+
+	if action == SET_SWAP {
+		for n := range wt.cells {
+			set := &wt.cells[n].Set
+			set[0], set[1] = set[1], set[0]
+		}
+		return
+	}
+
+	wt.check()
+}
+
+// Update replaces the data of each active cell with the data returned
+// by update, and untags the cell unless keep is set.
+func (wt *Watcher) Update(update func(data uint32) (new_data uint32, keep bool)) {
+	wt.Memory.Update(update)
+	wt.check()
+}
+
+// Reset fills the memory with 0xffffffff, and tags all cells.
+func (wt *Watcher) Reset() {
+	wt.Memory.Reset()
+	wt.Sync()
+}
+
+// Import replaces the memory with a copy of a set of cells.
+func (wt *Watcher) Import(cells []Cell) {
+	wt.Memory.Import(cells)
+	wt.Sync()
+}
@@ -0,0 +1,81 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package capp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, mem := range []Memory{NewCapp(8), NewBitPlane(8), NewSharded(8, 3, func(count uint) Memory { return NewBitPlane(count) })} {
+		wt := NewWatcher(mem)
+
+		appear := wt.Watch(Watchpoint{Kind: WATCH_APPEAR, Match: 0x10, Mask: 0xf0})
+		disappear := wt.Watch(Watchpoint{Kind: WATCH_DISAPPEAR, Match: 0x10, Mask: 0xf0})
+		change := wt.Watch(Watchpoint{Kind: WATCH_CHANGE, Match: 0x10, Mask: 0xf0, Bits: 0x0f})
+
+		// Cells 0 and 1 appear.
+		wt.Action(WRITE_FIRST, 0x11, 0xffffffff)
+		wt.Action(LIST_NEXT, 0, 0)
+		wt.Action(WRITE_FIRST, 0x12, 0xffffffff)
+		wt.Action(LIST_NEXT, 0, 0)
+		wt.Action(WRITE_FIRST, 0x20, 0xffffffff)
+		events := wt.Events()
+		assert.Len(events, 2)
+		for n, ev := range events {
+			assert.Equal(appear, ev.Id)
+			assert.Equal(uint(n), ev.Index)
+			assert.Equal(uint32(0xffffffff), ev.Old.Data)
+		}
+		assert.Empty(wt.Events())
+
+		// Cell 1 changes, but does not disappear.
+		wt.Action(LIST_ALL, 0, 0)
+		wt.Action(LIST_ONLY, 0x12, 0xff)
+		wt.Action(WRITE_LIST, 0x05, 0x0f)
+		events = wt.Events()
+		if assert.Len(events, 1) {
+			assert.Equal(change, events[0].Id)
+			assert.Equal(uint(1), events[0].Index)
+			assert.Equal(uint32(0x12), events[0].Old.Data)
+			assert.Equal(uint32(0x15), events[0].New.Data)
+		}
+
+		// Bits outside of the change mask are not watched.
+		wt.Action(SET_SWAP, 0, 0)
+		wt.Action(SET_SWAP, 0, 0)
+		wt.Action(WRITE_LIST, 0x15|0x100, 0x100)
+		assert.Empty(wt.Events())
+
+		// Cell 1 disappears, and cell 2 appears.
+		wt.Action(LIST_ALL, 0, 0)
+		wt.Action(LIST_ONLY, 0x115, 0xfff)
+		wt.Update(func(data uint32) (uint32, bool) { return 0x25, true })
+		wt.Action(LIST_ALL, 0, 0)
+		wt.Action(LIST_ONLY, 0x20, 0xff)
+		wt.Action(CARE_LIST, 0x00, 0xf0)
+		events = wt.Events()
+		if assert.Len(events, 2) {
+			assert.Equal(disappear, events[0].Id)
+			assert.Equal(uint(1), events[0].Index)
+			assert.Equal(appear, events[1].Id)
+			assert.Equal(uint(2), events[1].Index)
+		}
+
+		// Reset does not trigger watchpoints.
+		wt.Reset()
+		assert.Empty(wt.Events())
+		assert.NoError(wt.Unwatch(appear))
+		assert.ErrorIs(wt.Unwatch(appear), ErrWatchMissing)
+
+		var ids []int
+		for id := range wt.Watchpoints {
+			ids = append(ids, id)
+		}
+		assert.Equal([]int{disappear, change}, ids)
+	}
+}
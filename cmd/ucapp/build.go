@@ -3,6 +3,8 @@
 package main
 
 import (
+	"encoding/json"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -12,8 +14,12 @@ import (
 )
 
 type CliBuild struct {
-	Output string   `help:"Output file name. Default is <source>.ur"`
-	Source *os.File `arg:"" help:"Source file (*.uc) to compile"`
+	Output  string   `help:"Output file name. Default is <source>.ur"`
+	Listing string   `help:"Listing file name. Default is no listing"`
+	Symbols string   `help:"Symbol map file name. Default is no symbol map"`
+	Debug   bool     `help:"Write the debug info sidecar next to the output (somefile.urd)" default:"true" negatable:""`
+	Json    bool     `help:"Write the diagnostics to stdout as JSON, for editor integration"`
+	Source  *os.File `arg:"" help:"Source file (*.uc) to compile"`
 }
 
 func (cb *CliBuild) Run(opt *Options) (err error) {
@@ -29,13 +35,26 @@ func (cb *CliBuild) Run(opt *Options) (err error) {
 	}
 
 	asm.Clear()
+	var prog *cpu.Program
 	err = asm.Parse(cb.Source)
-	if err != nil {
-		log.Fatalf("%v: %v", cb.Source.Name(), err)
+	if err == nil {
+		prog, err = asm.Link()
+	}
+
+	if cb.Json {
+		diagnostics := cpu.Diagnostics(err)
+		if diagnostics == nil {
+			diagnostics = []cpu.Diagnostic{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(diagnostics)
+		if err != nil {
+			os.Exit(1)
+		}
 	}
-	prog, err := asm.Link()
 	if err != nil {
-		log.Fatalf("%v: %v", cb.Source.Name(), err)
+		fatalDiagnostics(err)
 	}
 
 	if len(cb.Output) == 0 {
@@ -59,5 +78,56 @@ func (cb *CliBuild) Run(opt *Options) (err error) {
 		return
 	}
 
+	if cb.Debug {
+		err = writeFile(debugPath(cb.Output), prog.MarshalDebug)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(cb.Listing) != 0 {
+		err = writeFile(cb.Listing, prog.WriteListing)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(cb.Symbols) != 0 {
+		err = writeFile(cb.Symbols, prog.WriteSymbols)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// fatalDiagnostics logs each diagnostic of an assembly error, and exits.
+func fatalDiagnostics(err error) {
+	for _, diagnostic := range cpu.Diagnostics(err) {
+		log.Print(diagnostic)
+	}
+	os.Exit(1)
+}
+
+// debugPath returns the path of the debug info sidecar of a ring file.
+func debugPath(ring string) string {
+	return strings.TrimSuffix(ring, ".ur") + ".urd"
+}
+
+// writeFile creates a file, and writes its content with a writer function.
+func writeFile(name string, write func(w io.Writer) error) (err error) {
+	file, err := os.Create(name)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	err = write(file)
+	if err != nil {
+		return
+	}
+
+	err = file.Close()
 	return
 }
@@ -0,0 +1,327 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/emulator"
+)
+
+// debugHelp is the help text for the debugger commands.
+const debugHelp = `Commands:
+  break, b [LOCATION]   Set a breakpoint, or list breakpoints.
+  delete, d LOCATION    Delete a breakpoint.
+  watch, wa [WATCH]     Set a content watchpoint, or list watchpoints.
+  unwatch ID            Delete a content watchpoint.
+  step, s [N]           Execute N (default 1) instruction codes.
+  next, n [N]           Execute N (default 1) source lines, stepping over calls.
+  continue, c           Execute until a breakpoint, or the program exits.
+  reverse-step, rs [N]  Step back N (default 1) instruction codes.
+  reverse-continue, rc  Step back until a breakpoint, or the start of the history.
+  regs, r               Show the registers, stack top, match, and mask.
+  stack                 Show the stack, top first.
+  capp [N]              Show the first N (default 16) items of the active CAPP list.
+  where, w              Show the current source line.
+  help, h               Show this help.
+  quit, q               Leave the debugger.
+A LOCATION is a label, a line number, or a filename:lineno pair.
+A WATCH is 'appear MATCH MASK' or 'disappear MATCH MASK', to stop when a
+CAPP cell starts or stops matching, or 'change MATCH MASK BITS', to stop
+when any of the BITS of a matching cell change.
+An empty line repeats the previous command.
+`
+
+// CliDebug handles the CLI 'debug' command.
+type CliDebug struct {
+	Input   string   `help:"Tape input (default is an empty tape)"`
+	Output  string   `help:"Tape output" default:"-"`
+	Break   []string `help:"Breakpoint location (label, line, or filename:lineno)" short:"b"`
+	Watch   []string `help:"Content watchpoint ('appear MATCH MASK', 'disappear MATCH MASK', or 'change MATCH MASK BITS')" sep:"none"`
+	History int      `help:"Instruction codes kept in the history for reverse execution (0 to disable)" default:"10000"`
+	Source  *os.File `arg:"" help:"Source file (*.uc) to debug"`
+}
+
+// Run executes the 'debug' command.
+func (cd *CliDebug) Run(opt *Options) (err error) {
+	emu := opt.Emulator
+
+	defer cd.Source.Close()
+
+	asm := &cpu.Assembler{}
+	for define, value := range emu.Defines() {
+		asm.Predefine(define, value)
+	}
+
+	asm.Clear()
+	err = asm.Parse(cd.Source)
+	if err != nil {
+		fatalDiagnostics(err)
+	}
+	emu.Program, err = asm.Link()
+	if err != nil {
+		fatalDiagnostics(err)
+	}
+
+	if len(cd.Input) == 0 {
+		// The host terminal's input belongs to the debugger.
+		emu.Tape.Input = strings.NewReader("")
+	} else {
+		inf, err := os.Open(cd.Input)
+		if err != nil {
+			log.Fatalf("%v: %v", cd.Input, err)
+		}
+		defer inf.Close()
+		emu.Tape.Input = inf
+	}
+
+	if cd.Output == "-" {
+		emu.Tape.Output = os.Stdout
+	} else {
+		ouf, err := os.Create(cd.Output)
+		if err != nil {
+			log.Fatalf("%v: %v", cd.Output, err)
+		}
+		defer ouf.Close()
+		emu.Tape.Output = ouf
+	}
+
+	if cd.History > 0 {
+		emu.EnableHistory(cd.History)
+	}
+
+	err = emu.Reset(cpu.CHANNEL_ID_MONITOR)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbg := emulator.NewDebugger(emu)
+	for _, location := range cd.Break {
+		_, err = dbg.Break(location)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, watch := range cd.Watch {
+		_, err = dbg.Watch(strings.Fields(watch)...)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	dd := &debugDriver{Debugger: dbg, Out: os.Stdout}
+	dd.show()
+
+	err = dd.Repl(os.Stdin)
+	return
+}
+
+// debugDriver runs debugger commands from a command line.
+type debugDriver struct {
+	*emulator.Debugger
+	Out io.Writer // Output of the commands.
+
+	done bool // Set when the program has exited.
+}
+
+// Repl reads and executes commands until the input ends, or 'quit'.
+func (dd *debugDriver) Repl(in io.Reader) (err error) {
+	scanner := bufio.NewScanner(in)
+
+	var last []string
+	for {
+		fmt.Fprint(dd.Out, "(ucapp) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(dd.Out)
+			err = scanner.Err()
+			return
+		}
+
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			words = last
+		}
+		if len(words) == 0 {
+			continue
+		}
+		last = words
+
+		if !dd.Command(words) {
+			return
+		}
+	}
+}
+
+// Command executes a single debugger command.
+// Returns false if the debugger should quit.
+func (dd *debugDriver) Command(words []string) (more bool) {
+	more = true
+
+	count := 1
+	if len(words) > 1 {
+		value, err := strconv.ParseUint(words[1], 0, 32)
+		if err == nil {
+			count = int(value)
+		}
+	}
+
+	switch words[0] {
+	case "break", "b":
+		if len(words) < 2 {
+			for _, ip := range slices.Sorted(maps.Keys(dd.Breakpoints)) {
+				fmt.Fprintf(dd.Out, "0x%04x: %v\n", ip, dd.Breakpoints[ip])
+			}
+			return
+		}
+		ip, err := dd.Break(words[1])
+		if err != nil {
+			fmt.Fprintln(dd.Out, err)
+			return
+		}
+		fmt.Fprintf(dd.Out, "breakpoint at 0x%04x: %v\n", ip, dd.describe(ip))
+	case "delete", "d":
+		if len(words) < 2 {
+			fmt.Fprintln(dd.Out, "delete: location missing")
+			return
+		}
+		err := dd.Clear(words[1])
+		if err != nil {
+			fmt.Fprintln(dd.Out, err)
+		}
+	case "watch", "wa":
+		if len(words) < 2 {
+			for id, wp := range dd.Cpu.Watchpoints() {
+				fmt.Fprintf(dd.Out, "%d: %v\n", id, wp)
+			}
+			return
+		}
+		id, err := dd.Watch(words[1:]...)
+		if err != nil {
+			fmt.Fprintln(dd.Out, err)
+			return
+		}
+		fmt.Fprintf(dd.Out, "watchpoint %d\n", id)
+	case "unwatch":
+		if len(words) < 2 {
+			fmt.Fprintln(dd.Out, "unwatch: watchpoint missing")
+			return
+		}
+		id, err := strconv.Atoi(words[1])
+		if err == nil {
+			err = dd.Cpu.Unwatch(id)
+		}
+		if err != nil {
+			fmt.Fprintln(dd.Out, err)
+		}
+	case "step", "s":
+		dd.run(count, dd.Step)
+	case "next", "n":
+		dd.run(count, dd.Next)
+	case "continue", "c":
+		dd.run(1, dd.Continue)
+	case "reverse-step", "rs":
+		dd.reverse(func() error { return dd.Emulator.StepBack(count) })
+	case "reverse-continue", "rc":
+		dd.reverse(dd.ReverseContinue)
+	case "regs", "r":
+		fmt.Fprint(dd.Out, dd.Cpu.String())
+	case "stack":
+		data := dd.Cpu.Stack.Data
+		for n := len(data) - 1; n >= 0; n-- {
+			fmt.Fprintf(dd.Out, "[%2d] %04X_%04X\n", len(data)-1-n, data[n]>>16, data[n]&0xffff)
+		}
+	case "capp":
+		if len(words) < 2 {
+			count = 16
+		}
+		fmt.Fprintf(dd.Out, "count: %d\n", dd.Cpu.Capp.Count())
+		n := 0
+		for data := range dd.Active() {
+			if n >= count {
+				fmt.Fprintln(dd.Out, "...")
+				break
+			}
+			fmt.Fprintf(dd.Out, "[%2d] %01X_%07X\n", n, data>>28, data&0xfffffff)
+			n++
+		}
+	case "where", "w":
+		dd.show()
+	case "help", "h":
+		fmt.Fprint(dd.Out, debugHelp)
+	case "quit", "q":
+		more = false
+	default:
+		fmt.Fprintf(dd.Out, "%v: unknown command (try 'help')\n", words[0])
+	}
+
+	return
+}
+
+// run executes a debugger action count times, and shows the result.
+func (dd *debugDriver) run(count int, action func() (bool, error)) {
+	if dd.done {
+		fmt.Fprintln(dd.Out, "program has exited")
+		return
+	}
+
+	for range count {
+		done, err := action()
+		if err != nil {
+			fmt.Fprintln(dd.Out, err)
+			break
+		}
+		if done {
+			dd.done = true
+			fmt.Fprintf(dd.Out, "program exited: %d ticks, %d power\n", dd.Ticks(), dd.Power())
+			return
+		}
+	}
+
+	ip := int(dd.Cpu.Ip)
+	if _, ok := dd.Breakpoints[ip]; ok {
+		fmt.Fprintf(dd.Out, "breakpoint %v\n", dd.Breakpoints[ip])
+	}
+	dd.show()
+}
+
+// reverse executes a debugger action that steps back, and shows the result.
+func (dd *debugDriver) reverse(action func() error) {
+	err := action()
+	if err != nil {
+		fmt.Fprintln(dd.Out, err)
+		return
+	}
+
+	dd.done = false
+
+	ip := int(dd.Cpu.Ip)
+	if _, ok := dd.Breakpoints[ip]; ok {
+		fmt.Fprintf(dd.Out, "breakpoint %v\n", dd.Breakpoints[ip])
+	}
+	dd.show()
+}
+
+// describe returns the source location and text of an IP.
+func (dd *debugDriver) describe(ip int) string {
+	where := dd.Program.Debug(uint16(ip & ^int(cpu.IP_MODE_MASK)))
+	if where.Opcode == nil {
+		return "(no source)"
+	}
+
+	return fmt.Sprintf("%v:%d [%d/%d] %v", where.Filename, where.LineNo,
+		where.Index+1, len(where.Codes), strings.Join(where.Words, " "))
+}
+
+// show shows the current IP and source line.
+func (dd *debugDriver) show() {
+	fmt.Fprintf(dd.Out, "0x%04x: %v\n", dd.Cpu.Ip, dd.describe(int(dd.Cpu.Ip)))
+}
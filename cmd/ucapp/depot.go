@@ -91,6 +91,8 @@ type CliDepotSave struct {
 func (cmd *CliDepotSave) Run(opt *Options) (err error) {
 	defer cmd.Source.Close()
 
+	var saved *sio.Ring // Ring the file was saved to.
+
 	// Select drum
 	err = selectDrum(&opt.Emulator.Depot, cmd.Drum)
 	if err != nil {
@@ -119,11 +121,25 @@ func (cmd *CliDepotSave) Run(opt *Options) (err error) {
 		for _, value := range content {
 			sio.SendAsUint8(&opt.Emulator.Depot, value)
 		}
+		saved = opt.Emulator.Depot.Ring
+		saved.Debug = nil
 	} else {
 		err = opt.Emulator.Depot.Save(cmd.Name, cmd.Source)
 		if err != nil {
 			return
 		}
+		for dirent := range opt.Emulator.Depot.Dirents() {
+			if !dirent.Deleted() && dirent.NameIs(cmd.Name) {
+				saved = opt.Emulator.Depot.Rings[dirent.Ring]
+				break
+			}
+		}
+	}
+
+	// Save the debug info sidecar of the file, if any.
+	debug, derr := os.ReadFile(debugPath(cmd.Source.Name()))
+	if derr == nil && saved != nil {
+		saved.Debug = debug
 	}
 
 	return
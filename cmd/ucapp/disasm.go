@@ -0,0 +1,67 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/sio"
+)
+
+// CliDisasm handles the CLI 'disasm' command.
+type CliDisasm struct {
+	Drum   uint32   `help:"Drum in the depot to disassemble (default is 0x000000)"`
+	Ring   uint8    `help:"Ring in the drum to disassemble (default is 0x00)"`
+	Output string   `help:"Output file name" default:"-"`
+	Source *os.File `arg:"" optional:"" help:"Ring file (*.ur) to disassemble, instead of a ring in the depot"`
+}
+
+// Run executes the 'disasm' command.
+func (cd *CliDisasm) Run(opt *Options) (err error) {
+	ring := &sio.Ring{}
+	if cd.Source != nil {
+		defer cd.Source.Close()
+		err = ring.Unmarshal(cd.Source)
+		if err != nil {
+			return
+		}
+	} else {
+		drum, ok := opt.Emulator.Depot.Drums[cd.Drum]
+		if !ok {
+			err = fmt.Errorf("drum 0x%06x does not exist", cd.Drum)
+			return
+		}
+		depotRing, ok := drum.Rings[cd.Ring]
+		if !ok {
+			err = fmt.Errorf("ring 0x%02x does not exist", cd.Ring)
+			return
+		}
+		err = ring.Unmarshal(bytes.NewReader(depotRing.Data))
+		if err != nil {
+			return
+		}
+	}
+
+	prog, err := cpu.Disassemble(slices.Collect(sio.ReceiveAsUint32(ring)))
+	if err != nil {
+		return
+	}
+
+	var output io.Writer = os.Stdout
+	if cd.Output != "-" {
+		ouf, err := os.Create(cd.Output)
+		if err != nil {
+			return err
+		}
+		defer ouf.Close()
+		output = ouf
+	}
+
+	err = prog.WriteSource(output)
+	return
+}
@@ -0,0 +1,231 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// DIFF_CONTEXT is the number of lines of context of a diff hunk.
+const DIFF_CONTEXT = 3
+
+// CliFmt handles the CLI 'fmt' command.
+type CliFmt struct {
+	List  bool     `short:"l" help:"List the files whose formatting differs from the canonical style"`
+	Diff  bool     `short:"d" help:"Show the diff of each file to the canonical style"`
+	Write bool     `short:"w" help:"Write the canonical style to the files, instead of to stdout"`
+	Paths []string `arg:"" optional:"" type:"path" help:"Source files (*.uc), or directories of source files, to format. Default is stdin"`
+}
+
+// Run executes the 'fmt' command. With --list or --diff, the exit status is
+// 1 if any file is not in the canonical style.
+func (cf *CliFmt) Run(opt *Options) (err error) {
+	if len(cf.Paths) == 0 {
+		var src []byte
+		src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return
+		}
+		var changed bool
+		changed, err = cf.format("<standard input>", src)
+		if err != nil {
+			return
+		}
+		if changed && (cf.List || cf.Diff) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	unformatted := false
+	for _, path := range cf.Paths {
+		err = filepath.WalkDir(path, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Walk directories, and the source files in them.
+			if entry.IsDir() || (name != path && filepath.Ext(name) != ".uc") {
+				return nil
+			}
+
+			src, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			changed, err := cf.format(name, src)
+			if changed {
+				unformatted = true
+			}
+			return err
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	if unformatted && (cf.List || cf.Diff) {
+		os.Exit(1)
+	}
+
+	return
+}
+
+// format formats the source of a file, and returns true if the formatting
+// changed the source.
+func (cf *CliFmt) format(name string, src []byte) (changed bool, err error) {
+	out := cpu.Format(src)
+	changed = !bytes.Equal(src, out)
+
+	if cf.List && changed {
+		fmt.Println(name)
+	}
+
+	if cf.Diff && changed {
+		_, err = os.Stdout.Write(unifiedDiff(name, src, out))
+		if err != nil {
+			return
+		}
+	}
+
+	if cf.Write && changed && name != "<standard input>" {
+		var info os.FileInfo
+		info, err = os.Stat(name)
+		if err != nil {
+			return
+		}
+		err = os.WriteFile(name, out, info.Mode().Perm())
+		if err != nil {
+			return
+		}
+	}
+
+	if !cf.List && !cf.Diff && (!cf.Write || name == "<standard input>") {
+		_, err = os.Stdout.Write(out)
+	}
+
+	return
+}
+
+// diffLine is a line of a diff: a ' ' common line, a '-' line of the old
+// text, or a '+' line of the new text.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns the unified diff of the old and new text of a file,
+// or nothing if they are the same.
+func unifiedDiff(name string, old []byte, new []byte) (diff []byte) {
+	a := strings.SplitAfter(string(old), "\n")
+	b := strings.SplitAfter(string(new), "\n")
+	if len(a[len(a)-1]) == 0 {
+		a = a[:len(a)-1]
+	}
+	if len(b[len(b)-1]) == 0 {
+		b = b[:len(b)-1]
+	}
+
+	// Length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{kind: ' ', text: a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{kind: '-', text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{kind: '+', text: b[j]})
+			j++
+		}
+	}
+
+	var buff bytes.Buffer
+	oldLine, newLine := 1, 1
+	for n := 0; n < len(lines); {
+		if lines[n].kind == ' ' {
+			oldLine++
+			newLine++
+			n++
+			continue
+		}
+
+		// Extend the hunk to the last change within twice the context.
+		start := max(n-DIFF_CONTEXT, 0)
+		end := n
+		for k := n; k < len(lines) && k <= end+2*DIFF_CONTEXT; k++ {
+			if lines[k].kind != ' ' {
+				end = k
+			}
+		}
+		end = min(end+DIFF_CONTEXT+1, len(lines))
+
+		oldStart, newStart := oldLine-(n-start), newLine-(n-start)
+		oldCount, newCount := 0, 0
+		var hunk bytes.Buffer
+		for _, line := range lines[start:end] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+			hunk.WriteByte(line.kind)
+			hunk.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		for _, line := range lines[n:end] {
+			if line.kind != '+' {
+				oldLine++
+			}
+			if line.kind != '-' {
+				newLine++
+			}
+		}
+
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&buff, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		buff.Write(hunk.Bytes())
+		n = end
+	}
+
+	if buff.Len() == 0 {
+		return
+	}
+
+	diff = fmt.Appendf(nil, "--- %v.orig\n+++ %v\n", name, name)
+	diff = append(diff, buff.Bytes()...)
+	return
+}
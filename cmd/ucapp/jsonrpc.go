@@ -0,0 +1,140 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSON-RPC error codes.
+const (
+	JSONRPC_PARSE_ERROR      = -32700
+	JSONRPC_METHOD_NOT_FOUND = -32601
+	JSONRPC_INVALID_PARAMS   = -32602
+)
+
+// jsonrpcMessage is a JSON-RPC 2.0 request, notification or response.
+// A request has an Id and a Method, a notification only a Method, and a
+// response an Id and either a Result or an Error.
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+// jsonrpcError is the error of a JSON-RPC response.
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the message of the error.
+func (je *jsonrpcError) Error() string {
+	return je.Message
+}
+
+// jsonrpcConn reads and writes JSON-RPC messages framed by a
+// Content-Length header, as used by the Language Server Protocol.
+type jsonrpcConn struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// newJsonrpcConn creates a JSON-RPC connection on a reader and writer.
+func newJsonrpcConn(r io.Reader, w io.Writer) (conn *jsonrpcConn) {
+	conn = &jsonrpcConn{
+		r: bufio.NewReader(r),
+		w: w,
+	}
+	return
+}
+
+// Read reads the next message.
+func (conn *jsonrpcConn) Read() (msg *jsonrpcMessage, err error) {
+	length := -1
+	for {
+		var header string
+		header, err = conn.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		header = strings.TrimSpace(header)
+		if len(header) == 0 {
+			break
+		}
+		name, value, ok := strings.Cut(header, ":")
+		if ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	if length < 0 {
+		err = &jsonrpcError{Code: JSONRPC_PARSE_ERROR, Message: "missing Content-Length"}
+		return
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(conn.r, body)
+	if err != nil {
+		return
+	}
+
+	msg = &jsonrpcMessage{}
+	err = json.Unmarshal(body, msg)
+	return
+}
+
+// Write writes a message.
+func (conn *jsonrpcConn) Write(msg *jsonrpcMessage) (err error) {
+	msg.Version = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	_, err = fmt.Fprintf(conn.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return
+}
+
+// Reply writes the response to a request, of either a result or, if
+// failure is not nil, an error.
+func (conn *jsonrpcConn) Reply(id json.RawMessage, result any, failure error) (err error) {
+	msg := &jsonrpcMessage{Id: id}
+	if failure != nil {
+		je, ok := failure.(*jsonrpcError)
+		if !ok {
+			je = &jsonrpcError{Code: JSONRPC_INVALID_PARAMS, Message: failure.Error()}
+		}
+		msg.Error = je
+	} else {
+		msg.Result, err = json.Marshal(result)
+		if err != nil {
+			return
+		}
+	}
+
+	err = conn.Write(msg)
+	return
+}
+
+// Notify writes a notification.
+func (conn *jsonrpcConn) Notify(method string, params any) (err error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+
+	err = conn.Write(&jsonrpcMessage{Method: method, Params: raw})
+	return
+}
@@ -0,0 +1,559 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// LSP diagnostic severities and completion item kinds.
+const (
+	LSP_SEVERITY_ERROR = 1
+
+	LSP_KIND_KEYWORD = 14
+)
+
+// lspPosition is a zero based line and character position in a document.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lspRange is a range in a document.
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspLocation is a range in a document.
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// lspDiagnostic is a diagnostic of a document.
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspTextDocument identifies a document, and has its text when opened.
+type lspTextDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text,omitempty"`
+}
+
+// lspParams are the parameters of the requests and notifications used.
+type lspParams struct {
+	RootURI        string          `json:"rootUri"`
+	TextDocument   lspTextDocument `json:"textDocument"`
+	Position       lspPosition     `json:"position"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// lspDocument is a document opened by the editor.
+type lspDocument struct {
+	path      string          // File path of the document.
+	text      string          // Text of the document.
+	asm       *cpu.Assembler  // Assembler state of the last assembly.
+	published map[string]bool // URIs with diagnostics of the last assembly.
+}
+
+// lspSource is the text of a document, as assembler input.
+type lspSource struct {
+	*strings.Reader
+	name string
+}
+
+// Name returns the file path of the document.
+func (ls *lspSource) Name() string {
+	return ls.name
+}
+
+// CliLsp handles the CLI 'lsp' command.
+type CliLsp struct {
+	Root string `help:"Directory of the .include paths (default is the workspace root of the editor)"`
+
+	conn      *jsonrpcConn
+	defines   map[string]string
+	documents map[string]*lspDocument
+}
+
+// Run executes the 'lsp' command, serving the Language Server Protocol on
+// stdin and stdout.
+func (cl *CliLsp) Run(opt *Options) (err error) {
+	cl.conn = newJsonrpcConn(os.Stdin, os.Stdout)
+	cl.defines = maps.Collect(opt.Emulator.Defines())
+
+	err = cl.serve(opt.Verbose)
+	return
+}
+
+// serve handles the requests and notifications of the connection, until
+// the 'exit' notification or the end of the input.
+func (cl *CliLsp) serve(verbose bool) (err error) {
+	cl.documents = map[string]*lspDocument{}
+
+	for {
+		var msg *jsonrpcMessage
+		msg, err = cl.conn.Read()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		if verbose {
+			log.Printf("lsp: %v", msg.Method)
+		}
+
+		if msg.Method == "exit" {
+			return
+		}
+
+		result, failure := cl.handle(msg)
+		if len(msg.Id) == 0 {
+			// Notifications have no response.
+			if failure != nil {
+				log.Printf("lsp: %v: %v", msg.Method, failure)
+			}
+			continue
+		}
+
+		err = cl.conn.Reply(msg.Id, result, failure)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle handles a request or notification, and returns its result.
+func (cl *CliLsp) handle(msg *jsonrpcMessage) (result any, err error) {
+	var params lspParams
+	if len(msg.Params) != 0 {
+		err = json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			return
+		}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		if len(cl.Root) == 0 {
+			cl.Root, _ = uriPath(params.RootURI)
+		}
+		result = map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    1, // Full document text.
+					"save":      true,
+				},
+				"definitionProvider": true,
+				"hoverProvider":      true,
+				"completionProvider": map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": "ucapp"},
+		}
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		// Nothing to do.
+	case "textDocument/didOpen":
+		var path string
+		path, err = uriPath(params.TextDocument.URI)
+		if err != nil {
+			return
+		}
+		doc := &lspDocument{path: path, text: params.TextDocument.Text}
+		cl.documents[params.TextDocument.URI] = doc
+		err = cl.assemble(doc)
+	case "textDocument/didChange":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok && len(params.ContentChanges) != 0 {
+			doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+	case "textDocument/didSave":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok {
+			err = cl.assemble(doc)
+		}
+	case "textDocument/didClose":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok {
+			delete(cl.documents, params.TextDocument.URI)
+			for uri := range doc.published {
+				err = cl.publish(uri, []lspDiagnostic{})
+				if err != nil {
+					return
+				}
+			}
+		}
+	case "textDocument/definition":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok {
+			result = cl.definition(doc, params.Position)
+		}
+	case "textDocument/hover":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok {
+			result = cl.hover(doc, params.Position)
+		}
+	case "textDocument/completion":
+		doc, ok := cl.documents[params.TextDocument.URI]
+		if ok {
+			result = cl.completion(doc, params.Position)
+		}
+	default:
+		err = &jsonrpcError{Code: JSONRPC_METHOD_NOT_FOUND, Message: "method not found: " + msg.Method}
+	}
+
+	return
+}
+
+// uriPath returns the file path of a 'file:' URI.
+func uriPath(uri string) (path string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return
+	}
+	if u.Scheme != "file" {
+		err = fmt.Errorf("unsupported URI: %v", uri)
+		return
+	}
+
+	path = filepath.FromSlash(u.Path)
+	return
+}
+
+// pathURI returns the 'file:' URI of a file path.
+func pathURI(path string) string {
+	path, _ = filepath.Abs(path)
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// lineOf returns a line, from 0, of a text.
+func lineOf(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line], "\r")
+}
+
+// wordAt returns the word of a line at a character position, and its range.
+func wordAt(line string, character int) (word string, start int, end int) {
+	line, _, _ = strings.Cut(line, ";")
+	character = min(character, len(line))
+
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' }
+
+	start = character
+	for start > 0 && !isSpace(line[start-1]) {
+		start--
+	}
+	end = character
+	for end < len(line) && !isSpace(line[end]) {
+		end++
+	}
+
+	word = line[start:end]
+	return
+}
+
+// sourceLine returns a line, from 1, of a source file: of the opened
+// document of the file, or else of the file.
+func (cl *CliLsp) sourceLine(path string, lineno int) string {
+	for _, doc := range cl.documents {
+		if doc.path == path {
+			return lineOf(doc.text, lineno-1)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return lineOf(string(data), lineno-1)
+}
+
+// location returns the LSP location of the line of a source file.
+func (cl *CliLsp) location(source cpu.Location) (loc lspLocation) {
+	line := max(source.LineNo-1, 0)
+	loc = lspLocation{
+		URI: pathURI(source.Filename),
+		Range: lspRange{
+			Start: lspPosition{Line: line},
+			End:   lspPosition{Line: line, Character: len(cl.sourceLine(source.Filename, source.LineNo))},
+		},
+	}
+	return
+}
+
+// assemble assembles a document, and publishes its diagnostics.
+func (cl *CliLsp) assemble(doc *lspDocument) (err error) {
+	root := cl.Root
+	if len(root) == 0 {
+		root = filepath.Dir(doc.path)
+	}
+
+	asm := &cpu.Assembler{FS: os.DirFS(root)}
+	for define, value := range cl.defines {
+		asm.Predefine(define, value)
+	}
+	asm.Clear()
+
+	doc.asm = asm
+
+	failure := asm.Parse(&lspSource{Reader: strings.NewReader(doc.text), name: doc.path})
+	if failure == nil {
+		_, failure = asm.Link()
+	}
+
+	diagnostics := map[string][]lspDiagnostic{}
+	for _, diagnostic := range cpu.Diagnostics(failure) {
+		filename := diagnostic.Filename
+		if len(filename) == 0 || filename == "stdin" {
+			filename = doc.path
+		}
+		uri := pathURI(filename)
+
+		loc := cl.location(cpu.Location{Filename: filename, LineNo: diagnostic.LineNo})
+		if diagnostic.Column != 0 {
+			_, start, end := wordAt(cl.sourceLine(filename, diagnostic.LineNo), diagnostic.Column-1)
+			loc.Range.Start.Character = start
+			loc.Range.End.Character = end
+		}
+
+		message := diagnostic.Message
+		for _, macro := range diagnostic.Macro {
+			message += fmt.Sprintf(" (in %v)", macro)
+		}
+
+		diagnostics[uri] = append(diagnostics[uri], lspDiagnostic{
+			Range:    loc.Range,
+			Severity: LSP_SEVERITY_ERROR,
+			Source:   "ucapp",
+			Message:  message,
+		})
+	}
+
+	// Clear the diagnostics of the document, and of files that no longer
+	// have any.
+	for _, uri := range append(slices.Collect(maps.Keys(doc.published)), pathURI(doc.path)) {
+		if _, ok := diagnostics[uri]; !ok {
+			diagnostics[uri] = []lspDiagnostic{}
+		}
+	}
+
+	doc.published = map[string]bool{}
+	for _, uri := range slices.Sorted(maps.Keys(diagnostics)) {
+		if len(diagnostics[uri]) != 0 {
+			doc.published[uri] = true
+		}
+		err = cl.publish(uri, diagnostics[uri])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// publish sends the diagnostics of a file to the editor.
+func (cl *CliLsp) publish(uri string, diagnostics []lspDiagnostic) error {
+	return cl.conn.Notify("textDocument/publishDiagnostics", map[string]any{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// opcodes returns the opcodes assembled from a line, from 1, of a
+// document, including those of a macro invocation on the line.
+func (doc *lspDocument) opcodes(lineno int) (opcodes []cpu.Opcode) {
+	invocation := fmt.Sprintf(" %v:%d", doc.path, lineno)
+	for _, op := range doc.asm.Opcode {
+		invoked := doc.asm.Expansion(op.Ip)
+		switch {
+		case op.Filename == doc.path && op.LineNo == lineno:
+		case len(invoked) != 0 && strings.HasSuffix(invoked[len(invoked)-1], invocation):
+		default:
+			continue
+		}
+		opcodes = append(opcodes, op)
+	}
+	return
+}
+
+// definition returns the location of the definition of the label, equate,
+// macro or .include path at a position of a document.
+func (cl *CliLsp) definition(doc *lspDocument, pos lspPosition) (result *lspLocation) {
+	if doc.asm == nil {
+		return
+	}
+
+	line := lineOf(doc.text, pos.Line)
+	word, _, _ := wordAt(line, pos.Character)
+	word = strings.TrimSuffix(word, ":")
+	if len(word) == 0 {
+		return
+	}
+
+	found := func(source cpu.Location) {
+		loc := cl.location(source)
+		result = &loc
+	}
+
+	words := strings.Fields(strings.Split(line, ";")[0])
+	if len(words) == 2 && words[0] == ".include" && words[1] == word {
+		root := cl.Root
+		if len(root) == 0 {
+			root = filepath.Dir(doc.path)
+		}
+		found(cpu.Location{Filename: filepath.Join(root, word), LineNo: 1})
+		return
+	}
+
+	asm := doc.asm
+	if macro, ok := asm.Macro[word]; ok {
+		// The macro's line is that of its first line of text.
+		found(cpu.Location{Filename: macro.Filename, LineNo: macro.LineNo - 1})
+		return
+	}
+
+	if source, ok := asm.EquateSource[word]; ok {
+		found(source)
+		return
+	}
+
+	for _, op := range doc.opcodes(pos.Line + 1) {
+		if len(op.LinkLabel) == 0 || !strings.HasSuffix(op.LinkLabel, word) {
+			continue
+		}
+		if source, ok := asm.LabelDefinition(op.LinkLabel, op.Ip); ok {
+			found(source)
+			return
+		}
+	}
+
+	if source, ok := asm.LabelSource[word]; ok {
+		found(source)
+		return
+	}
+
+	return
+}
+
+// hover returns the description of the word at a position of a document:
+// the value of an equate, the arguments and expanded codes of a macro, the
+// IP of a label, or else the encoded codes of the instruction of the line.
+// Each code is shown with its disassembly.
+func (cl *CliLsp) hover(doc *lspDocument, pos lspPosition) (result map[string]any) {
+	if doc.asm == nil {
+		return
+	}
+
+	line := lineOf(doc.text, pos.Line)
+	word, start, end := wordAt(line, pos.Character)
+	label := strings.TrimSuffix(word, ":")
+
+	asm := doc.asm
+
+	var text []string
+	codes := func() {
+		for _, op := range doc.opcodes(pos.Line + 1) {
+			for n, code := range op.Codes {
+				var imms []string
+				for _, imm := range code.Immediates {
+					imms = append(imms, fmt.Sprintf("%04x", imm))
+				}
+				source := ""
+				words, label, err := cpu.DisassembleCode(code)
+				if err == nil {
+					if len(label) != 0 && len(op.LinkLabel) != 0 && n == len(op.Codes)-1 {
+						words[len(words)-1] = op.LinkLabel
+					}
+					source = "; " + strings.Join(words, " ")
+				}
+				text = append(text, strings.TrimRight(fmt.Sprintf("%04x %04x %-9v %v",
+					op.Ip+n, code.Word, strings.Join(imms, " "), source), " "))
+			}
+		}
+	}
+
+	if value, ok := asm.Equate[word]; ok && len(word) != 0 {
+		text = append(text, fmt.Sprintf(".equ %v %v", word, value))
+	} else if macro, ok := asm.Macro[word]; ok {
+		text = append(text, strings.Join(append([]string{".macro", word}, macro.Args...), " "))
+		codes()
+	} else if ip, ok := asm.Label[label]; ok && len(label) != 0 {
+		text = append(text, fmt.Sprintf("%v: ; IP 0x%04x", label, ip))
+	} else {
+		codes()
+		start, end = 0, len(line)
+	}
+
+	if len(text) == 0 {
+		return
+	}
+
+	result = map[string]any{
+		"contents": map[string]any{
+			"kind":  "markdown",
+			"value": "```\n" + strings.Join(text, "\n") + "\n```",
+		},
+		"range": lspRange{
+			Start: lspPosition{Line: pos.Line, Character: start},
+			End:   lspPosition{Line: pos.Line, Character: end},
+		},
+	}
+	return
+}
+
+// completion returns the completions of the word at a position of a
+// document.
+func (cl *CliLsp) completion(doc *lspDocument, pos lspPosition) (items []map[string]any) {
+	asm := doc.asm
+	if asm == nil {
+		asm = &cpu.Assembler{}
+		asm.Clear()
+	}
+
+	line := lineOf(doc.text, pos.Line)
+	line = line[:min(pos.Character, len(line))]
+	if strings.Contains(line, ";") {
+		return
+	}
+
+	words := strings.Fields(line)
+	if len(words) != 0 && !strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\t") {
+		// The last word is being completed.
+		words = words[:len(words)-1]
+	}
+
+	items = []map[string]any{}
+	for _, name := range asm.Complete(words) {
+		items = append(items, map[string]any{
+			"label": name,
+			"kind":  LSP_KIND_KEYWORD,
+		})
+	}
+	return
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lspFrame returns a message framed by its Content-Length header.
+func lspFrame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+// lspSession serves the framed requests, and returns the messages written.
+func lspSession(t *testing.T, cl *CliLsp, requests []string) (replies []string) {
+	var input strings.Builder
+	for _, request := range requests {
+		input.WriteString(lspFrame(request))
+	}
+
+	var output bytes.Buffer
+	cl.conn = newJsonrpcConn(strings.NewReader(input.String()), &output)
+	err := cl.serve(false)
+	assert.NoError(t, err)
+
+	conn := newJsonrpcConn(&output, nil)
+	for {
+		msg, err := conn.Read()
+		if errors.Is(err, io.EOF) || !assert.NoError(t, err) {
+			break
+		}
+		raw, err := json.Marshal(msg)
+		assert.NoError(t, err)
+		replies = append(replies, string(raw))
+	}
+	return
+}
+
+func TestJsonrpcConn(t *testing.T) {
+	assert := assert.New(t)
+
+	input := lspFrame(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`) +
+		"content-length: 33\r\nContent-Type: application/json\r\n\r\n" +
+		`{"jsonrpc":"2.0","method":"exit"}` +
+		"Content-Type: application/json\r\n\r\n{}"
+
+	conn := newJsonrpcConn(strings.NewReader(input), nil)
+
+	msg, err := conn.Read()
+	assert.NoError(err)
+	assert.Equal("initialize", msg.Method)
+	assert.Equal(json.RawMessage("1"), msg.Id)
+
+	msg, err = conn.Read()
+	assert.NoError(err)
+	assert.Equal("exit", msg.Method)
+	assert.Empty(msg.Id)
+
+	_, err = conn.Read()
+	assert.ErrorContains(err, "missing Content-Length")
+
+	var output bytes.Buffer
+	conn = newJsonrpcConn(nil, &output)
+	assert.NoError(conn.Reply(json.RawMessage("2"), []int{1}, nil))
+	assert.NoError(conn.Reply(json.RawMessage(`"a"`), nil, fmt.Errorf("bad")))
+	assert.NoError(conn.Notify("note", map[string]int{"x": 1}))
+	assert.Equal(lspFrame(`{"jsonrpc":"2.0","id":2,"result":[1]}`)+
+		lspFrame(`{"jsonrpc":"2.0","id":"a","error":{"code":-32602,"message":"bad"}}`)+
+		lspFrame(`{"jsonrpc":"2.0","method":"note","params":{"x":1}}`), output.String())
+}
+
+func TestUriPath(t *testing.T) {
+	assert := assert.New(t)
+
+	table := []struct {
+		URI  string
+		Path string
+		Err  bool
+	}{
+		{URI: "file:///tmp/main.uc", Path: "/tmp/main.uc"},
+		{URI: "file:///tmp/my%20dir/main.uc", Path: "/tmp/my dir/main.uc"},
+		{URI: "http://host/main.uc", Err: true},
+		{URI: "%zz", Err: true},
+	}
+
+	for _, entry := range table {
+		path, err := uriPath(entry.URI)
+		if entry.Err {
+			assert.Error(err, entry.URI)
+			continue
+		}
+		assert.NoError(err, entry.URI)
+		assert.Equal(filepath.FromSlash(entry.Path), path, entry.URI)
+		assert.Equal(entry.URI, pathURI(path), entry.URI)
+	}
+}
+
+func TestWordAt(t *testing.T) {
+	assert := assert.New(t)
+
+	table := []struct {
+		Line      string
+		Character int
+		Word      string
+		Start     int
+		End       int
+	}{
+		{Line: "alu add r0 COUNT", Character: 0, Word: "alu", Start: 0, End: 3},
+		{Line: "alu add r0 COUNT", Character: 3, Word: "alu", Start: 0, End: 3},
+		{Line: "alu add r0 COUNT", Character: 13, Word: "COUNT", Start: 11, End: 16},
+		{Line: "alu add r0 COUNT", Character: 99, Word: "COUNT", Start: 11, End: 16},
+		{Line: "\tcall\tMain ; Main", Character: 7, Word: "Main", Start: 6, End: 10},
+		{Line: "jump Main ; Main", Character: 13, Word: "", Start: 10, End: 10},
+		{Line: "a  b", Character: 2, Word: "", Start: 2, End: 2},
+	}
+
+	for _, entry := range table {
+		word, start, end := wordAt(entry.Line, entry.Character)
+		assert.Equal(entry.Word, word, "%q:%d", entry.Line, entry.Character)
+		assert.Equal(entry.Start, start, "%q:%d", entry.Line, entry.Character)
+		assert.Equal(entry.End, end, "%q:%d", entry.Line, entry.Character)
+	}
+}
+
+func TestCliLsp(t *testing.T) {
+	assert := assert.New(t)
+
+	root := t.TempDir()
+	lib := filepath.Join(root, "lib.uc")
+	err := os.WriteFile(lib, []byte(".equ LIB_VALUE 7\nLibFunc:\nreturn\n"), 0o644)
+	assert.NoError(err)
+
+	main := filepath.Join(root, "main.uc")
+	mainURI := pathURI(main)
+	libURI := pathURI(lib)
+
+	text := strings.Join([]string{
+		".include lib.uc",
+		".equ COUNT 3",
+		"Main:",
+		"alu add r0 COUNT",
+		"call LibFunc",
+		"jump Main",
+		"",
+	}, "\n")
+	broken := strings.Replace(text, "COUNT\n", "COUNTS\n", 1)
+
+	quote := func(s string) string {
+		raw, _ := json.Marshal(s)
+		return string(raw)
+	}
+	position := func(method string, id int, line int, character int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":{"textDocument":{"uri":%q},"position":{"line":%d,"character":%d}}}`,
+			id, method, mainURI, line, character)
+	}
+	document := func(method string, text string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","method":%q,"params":{"textDocument":{"uri":%q},"contentChanges":[{"text":%v}]}}`,
+			method, mainURI, quote(text))
+	}
+
+	requests := []string{
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":%q}}`, pathURI(root)),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":%q,"text":%v}}}`, mainURI, quote(text)),
+		position("textDocument/definition", 2, 4, 7),
+		position("textDocument/definition", 3, 0, 10),
+		position("textDocument/definition", 4, 3, 12),
+		position("textDocument/hover", 5, 1, 6),
+		position("textDocument/hover", 6, 2, 1),
+		position("textDocument/hover", 7, 4, 1),
+		position("textDocument/hover", 8, 3, 1),
+		position("textDocument/completion", 9, 5, 5),
+		document("textDocument/didChange", broken),
+		document("textDocument/didSave", ""),
+		document("textDocument/didChange", text),
+		document("textDocument/didSave", ""),
+		document("textDocument/didChange", broken),
+		document("textDocument/didSave", ""),
+		document("textDocument/didClose", ""),
+		`{"jsonrpc":"2.0","id":10,"method":"unknown/method"}`,
+		`{"jsonrpc":"2.0","id":11,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":12,"method":"shutdown"}`,
+	}
+
+	diagnostic := `{"range":{"start":{"line":3,"character":11},"end":{"line":3,"character":17}},"severity":1,"source":"ucapp","message":"COUNTS is not a number (did you mean 'COUNT', 'count'?)"}`
+	publish := func(diagnostics string) string {
+		return `{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"MAIN","diagnostics":[` + diagnostics + `]}}`
+	}
+	hover := func(id int, value string, line int, start int, end int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"contents":{"kind":"markdown","value":%v},"range":{"start":{"line":%d,"character":%d},"end":{"line":%d,"character":%d}}}}`,
+			id, quote("```\n"+value+"\n```"), line, start, line, end)
+	}
+
+	expected := []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"completionProvider":{},"definitionProvider":true,"hoverProvider":true,"textDocumentSync":{"change":1,"openClose":true,"save":true}},"serverInfo":{"name":"ucapp"}}}`,
+		// didOpen
+		publish(""),
+		// Label of an included file.
+		`{"jsonrpc":"2.0","id":2,"result":{"uri":"LIB","range":{"start":{"line":1,"character":0},"end":{"line":1,"character":8}}}}`,
+		// .include path.
+		`{"jsonrpc":"2.0","id":3,"result":{"uri":"LIB","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":16}}}}`,
+		// Equate.
+		`{"jsonrpc":"2.0","id":4,"result":{"uri":"MAIN","range":{"start":{"line":1,"character":0},"end":{"line":1,"character":12}}}}`,
+		hover(5, ".equ COUNT 3", 1, 5, 10),
+		hover(6, "Main: ; IP 0x0001", 2, 0, 5),
+		hover(7, "0002 007e 0001      ; alu set stack 0x1\n0003 0676           ; alu add stack ip\n0004 006f 0000 0000 ; jump LibFunc", 4, 0, 12),
+		hover(8, "0001 060e 0003      ; alu add r0 0x3", 3, 0, 16),
+		`{"jsonrpc":"2.0","id":9,"result":[{"kind":14,"label":"LibFunc"},{"kind":14,"label":"Main"}]}`,
+		// didSave of the broken, fixed, and broken text.
+		publish(diagnostic),
+		publish(""),
+		publish(diagnostic),
+		// didClose
+		publish(""),
+		`{"jsonrpc":"2.0","id":10,"error":{"code":-32601,"message":"method not found: unknown/method"}}`,
+		`{"jsonrpc":"2.0","id":11,"result":null}`,
+	}
+
+	cl := &CliLsp{}
+	replies := lspSession(t, cl, requests)
+
+	uris := strings.NewReplacer(`"MAIN"`, quote(mainURI), `"LIB"`, quote(libURI))
+	if assert.Len(replies, len(expected)) {
+		for n, reply := range replies {
+			assert.JSONEq(uris.Replace(expected[n]), reply, "reply %d", n)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"log"
 	"os"
 
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/coproc"
 	"github.com/ezrec/ucapp/emulator"
 	"github.com/ezrec/ucapp/sio"
 
@@ -37,12 +39,21 @@ func (cf *createFS) Sub(name string) (sub sio.CreateFS, err error) {
 }
 
 type Cli struct {
-	Verbose   bool   `help:"Enter verbose mode"`
-	DepotPath string `help:"Path to the depot to use." name:"depot" default:"depot/"`
-
-	Build CliBuild `cmd:"" help:"Build a ucapp program"`
-	Depot CliDepot `cmd:"" help:"Manage the drum depot"`
-	Run   CliRun   `cmd:"" help:"Run a ucapp program in the emulator"`
+	Verbose    bool     `help:"Enter verbose mode"`
+	DepotPath  string   `help:"Path to the depot to use." name:"depot" default:"depot/"`
+	Capp       string   `help:"CAPP implementation (cell, bitplane)" enum:"cell,bitplane" default:"cell"`
+	CappSize   uint     `help:"Number of CAPP cells" default:"8192"`
+	CappShards uint     `help:"Number of CAPP shards, evaluated concurrently" default:"1"`
+	Coproc     []string `help:"Bind a coprocessor to a slot, as cpN=NAME (maxmin, arith, ternary)" placeholder:"cpN=NAME"`
+
+	Build  CliBuild  `cmd:"" help:"Build a ucapp program"`
+	Debug  CliDebug  `cmd:"" help:"Debug a ucapp program in the emulator"`
+	Depot  CliDepot  `cmd:"" help:"Manage the drum depot"`
+	Disasm CliDisasm `cmd:"" help:"Disassemble a ucapp ring"`
+	Fmt    CliFmt    `cmd:"" help:"Format ucapp programs in the canonical style"`
+	Lsp    CliLsp    `cmd:"" help:"Serve the Language Server Protocol for ucapp programs on stdio"`
+	Run    CliRun    `cmd:"" help:"Run a ucapp program in the emulator"`
+	Trace  CliTrace  `cmd:"" help:"Inspect a ucapp execution trace"`
 }
 
 type Options struct {
@@ -57,9 +68,24 @@ func main() {
 	var cli Cli
 	ctx := kong.Parse(&cli)
 
-	emu := emulator.NewEmulator()
+	newMemory := func(count uint) capp.Memory { return capp.NewCapp(count) }
+	if cli.Capp == "bitplane" {
+		newMemory = func(count uint) capp.Memory { return capp.NewBitPlane(count) }
+	}
+
+	newCapp := newMemory
+	if cli.CappShards > 1 {
+		newCapp = func(count uint) capp.Memory { return capp.NewSharded(count, cli.CappShards, newMemory) }
+	}
+
+	emu := emulator.NewEmulator(emulator.WithCappSize(cli.CappSize), emulator.WithCapp(newCapp))
 	defer emu.Close()
 
+	err = coproc.Bind(emu.Cpu, cli.Coproc...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	emu.Verbose = cli.Verbose
 
 	var root *os.Root
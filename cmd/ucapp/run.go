@@ -3,10 +3,14 @@
 package main
 
 import (
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/emulator"
 	"github.com/ezrec/ucapp/sio"
 )
 
@@ -15,6 +19,14 @@ type CliRun struct {
 	Ring   uint8  `help:"Ring in the drum to run (default is 0x00)"`
 	Input  string `help:"Tape input" default:"-"`
 	Output string `help:"Tape output" default:"-"`
+	Vt     bool   `help:"Show the Virtual Terminal on the host terminal (Ctrl-] to quit)"`
+	Trace  string `help:"Record every executed instruction to a trace file"`
+
+	SaveState string `help:"Save the emulator state to a file when the run stops (at exit, on an error, or on an interrupt)"`
+	LoadState string `help:"Resume from an emulator state saved by --save-state"`
+
+	Profile       string `help:"Write a profile of the ticks and power used by each IP, source line, and label"`
+	ProfileFormat string `help:"Profile format (pprof, text)" enum:"pprof,text" default:"pprof"`
 }
 
 func (cr *CliRun) Run(opt *Options) (err error) {
@@ -38,7 +50,10 @@ func (cr *CliRun) Run(opt *Options) (err error) {
 
 	boot := cpu.CHANNEL_ID_DEPOT
 
-	if cr.Input == "-" {
+	if cr.Input == "-" && cr.Vt {
+		// The host terminal's input belongs to the VT.
+		emu.Tape.Input = strings.NewReader("")
+	} else if cr.Input == "-" {
 		emu.Tape.Input = os.Stdin
 	} else {
 		inf, err := os.Open(cr.Input)
@@ -49,7 +64,10 @@ func (cr *CliRun) Run(opt *Options) (err error) {
 		emu.Tape.Input = inf
 	}
 
-	if cr.Output == "-" {
+	if cr.Output == "-" && cr.Vt {
+		// The host terminal's output belongs to the VT.
+		emu.Tape.Output = io.Discard
+	} else if cr.Output == "-" {
 		emu.Tape.Output = os.Stdout
 	} else {
 		ouf, err := os.Create(cr.Output)
@@ -65,10 +83,102 @@ func (cr *CliRun) Run(opt *Options) (err error) {
 		log.Fatal(err)
 	}
 
+	if len(cr.LoadState) != 0 {
+		err = cr.loadState(emu)
+		if err != nil {
+			log.Fatalf("%v: %v", cr.LoadState, err)
+		}
+	}
+
+	var tw *cpu.TraceWriter
+	if len(cr.Trace) != 0 {
+		trf, err := os.Create(cr.Trace)
+		if err != nil {
+			log.Fatalf("%v: %v", cr.Trace, err)
+		}
+		defer trf.Close()
+		tw = cpu.NewTraceWriter(trf, emu.Cpu.TraceState())
+		emu.Cpu.Tracer = tw
+	}
+
+	var prof *cpu.Profile
+	if len(cr.Profile) != 0 {
+		prof = cpu.NewProfile()
+		if emu.Cpu.Tracer != nil {
+			emu.Cpu.Tracer = cpu.MultiTracer{emu.Cpu.Tracer, prof}
+		} else {
+			emu.Cpu.Tracer = prof
+		}
+	}
+
+	var va *vtAnsi
+	if cr.Vt {
+		va = &vtAnsi{Vt: &emu.Vt}
+		err = va.Open()
+		if err != nil {
+			log.Fatalf("vt: %v", err)
+		}
+		defer va.Close()
+	}
+
+	// An interrupt pauses the run, so that its state can be saved.
+	interrupt := make(chan os.Signal, 1)
+	if len(cr.SaveState) != 0 {
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+	}
+
 	for done, err := emu.Tick(); !done; done, err = emu.Tick() {
 		if err != nil {
+			if va != nil {
+				va.Close()
+			}
+			if tw != nil {
+				tw.Flush()
+			}
+			if prof != nil {
+				cr.writeProfile(prof, emu.Program)
+			}
+			if len(cr.SaveState) != 0 {
+				cr.saveState(emu)
+			}
 			log.Fatal(err)
 		}
+		if va != nil && va.Quitting() {
+			break
+		}
+		if interrupted(interrupt) {
+			log.Printf("interrupted at ip 0x%x, tick %d", emu.Cpu.Ip, emu.Ticks())
+			break
+		}
+	}
+
+	if va != nil {
+		err = va.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	if tw != nil {
+		err = tw.Flush()
+		if err != nil {
+			return
+		}
+	}
+
+	if prof != nil {
+		err = cr.writeProfile(prof, emu.Program)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(cr.SaveState) != 0 {
+		err = cr.saveState(emu)
+		if err != nil {
+			return
+		}
 	}
 
 	if opt.Verbose {
@@ -87,3 +197,45 @@ func (cr *CliRun) Run(opt *Options) (err error) {
 
 	return
 }
+
+// interrupted returns true if an interrupt signal is pending.
+func interrupted(interrupt chan os.Signal) bool {
+	select {
+	case <-interrupt:
+		return true
+	default:
+		return false
+	}
+}
+
+// loadState restores the emulator state from the --load-state file.
+func (cr *CliRun) loadState(emu *emulator.Emulator) (err error) {
+	inf, err := os.Open(cr.LoadState)
+	if err != nil {
+		return
+	}
+	defer inf.Close()
+
+	err = emu.UnmarshalState(inf)
+	return
+}
+
+// saveState writes the emulator state to the --save-state file.
+func (cr *CliRun) saveState(emu *emulator.Emulator) (err error) {
+	err = writeFile(cr.SaveState, emu.MarshalState)
+	if err != nil {
+		log.Printf("%v: %v", cr.SaveState, err)
+	}
+	return
+}
+
+// writeProfile writes the profile in the selected format.
+func (cr *CliRun) writeProfile(prof *cpu.Profile, prog *cpu.Program) (err error) {
+	err = writeFile(cr.Profile, func(w io.Writer) error {
+		if cr.ProfileFormat == "text" {
+			return prof.WriteReport(w, prog)
+		}
+		return prof.WritePprof(w, prog)
+	})
+	return
+}
@@ -0,0 +1,133 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// CliTrace handles the CLI 'trace' command.
+type CliTrace struct {
+	Tick   int      `help:"Tick to seek to in the trace" default:"0"`
+	Count  int      `help:"Number of instructions to list from the tick" default:"0"`
+	Debug  *os.File `help:"Debug info (*.urd) to show the source of each instruction"`
+	Output string   `help:"Output file name" default:"-"`
+	Source *os.File `arg:"" help:"Trace file to inspect"`
+}
+
+// Run executes the 'trace' command.
+func (ct *CliTrace) Run(opt *Options) (err error) {
+	defer ct.Source.Close()
+
+	var prog *cpu.Program
+	if ct.Debug != nil {
+		defer ct.Debug.Close()
+		prog, err = cpu.UnmarshalDebug(ct.Debug)
+		if err != nil {
+			return
+		}
+	}
+
+	tr, err := cpu.NewTraceReader(ct.Source)
+	if err != nil {
+		return
+	}
+
+	var output io.Writer = os.Stdout
+	if ct.Output != "-" {
+		ouf, err := os.Create(ct.Output)
+		if err != nil {
+			return err
+		}
+		defer ouf.Close()
+		output = ouf
+	}
+
+	err = tr.Seek(ct.Tick)
+	if errors.Is(err, io.EOF) {
+		err = fmt.Errorf("trace ends at tick %d", tr.State.Tick)
+	}
+	if err != nil {
+		return
+	}
+
+	traceState(output, &tr.State)
+
+	for range ct.Count {
+		var event *cpu.TraceEvent
+		tick := tr.State.Tick
+		event, err = tr.Next()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		traceEvent(output, tick, event, prog)
+	}
+
+	return
+}
+
+// traceState shows the CPU state at a point in a trace.
+func traceState(w io.Writer, state *cpu.TraceState) {
+	fmt.Fprintf(w, " tick: %d\n", state.Tick)
+	fmt.Fprintf(w, "   ip: 0x%08x\n", state.Ip)
+	fmt.Fprintf(w, " cond: %v\n", state.Cond)
+	for n, value := range state.Register {
+		fmt.Fprintf(w, "   r%d: 0x%08x\n", n, value)
+	}
+	fmt.Fprintf(w, "match: 0x%08x\n", state.Match)
+	fmt.Fprintf(w, " mask: 0x%08x\n", state.Mask)
+	for n := len(state.Stack) - 1; n >= 0; n-- {
+		fmt.Fprintf(w, "stack: 0x%08x\n", state.Stack[n])
+	}
+	fmt.Fprintf(w, "count: %d\n", state.Count)
+	fmt.Fprintf(w, "first: 0x%08x\n", state.First)
+	fmt.Fprintf(w, "power: %d\n", state.Power)
+}
+
+// traceEvent shows a traced instruction on a single line: the tick, IP,
+// code (or source, if a program is known), and the changes it made.
+func traceEvent(w io.Writer, tick int, event *cpu.TraceEvent, prog *cpu.Program) {
+	where := event.Code().String()
+	if prog != nil && (event.Ip&cpu.IP_MODE_MASK) == cpu.IP_MODE_CAPP {
+		dbg := prog.Debug(uint16(event.Ip))
+		if dbg.Opcode != nil {
+			where = fmt.Sprintf("%s:%d %s", dbg.Filename, dbg.LineNo, strings.Join(dbg.Words, " "))
+		}
+	}
+
+	var changes []string
+	for _, reg := range event.Register {
+		changes = append(changes, fmt.Sprintf("%s=0x%08x", reg.Name, reg.Value))
+	}
+	if event.Pop != 0 {
+		changes = append(changes, fmt.Sprintf("pop=%d", event.Pop))
+	}
+	for _, value := range event.Push {
+		changes = append(changes, fmt.Sprintf("push=0x%08x", value))
+	}
+	if event.Next != event.Ip+1 {
+		changes = append(changes, fmt.Sprintf("ip=0x%08x", event.Next))
+	}
+	changes = append(changes,
+		fmt.Sprintf("cond=%v", event.Cond),
+		fmt.Sprintf("count=%d", event.Count),
+		fmt.Sprintf("first=0x%08x", event.First),
+		fmt.Sprintf("flipped=%d", event.BitsFlipped),
+		fmt.Sprintf("alu=%d", event.AluFlipped),
+	)
+	if len(event.Err) != 0 {
+		changes = append(changes, fmt.Sprintf("err=%q", event.Err))
+	}
+
+	fmt.Fprintf(w, "%8d %08x %s\n         %s\n", tick, event.Ip, where, strings.Join(changes, " "))
+}
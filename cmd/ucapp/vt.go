@@ -0,0 +1,294 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/ezrec/ucapp/sio"
+)
+
+const (
+	// vtQuitKey is the host key (Ctrl-]) that leaves the emulator.
+	vtQuitKey = 0x1d
+	// vtRefresh is the interval between screen refreshes.
+	vtRefresh = time.Second / 30
+)
+
+// vtCsiKeys maps 'ESC [ <final>' and 'ESC O <final>' sequences to VT keycodes.
+var vtCsiKeys = map[byte]uint8{
+	'A': sio.VT_KEY_UP,
+	'B': sio.VT_KEY_DOWN,
+	'C': sio.VT_KEY_RIGHT,
+	'D': sio.VT_KEY_LEFT,
+	'H': sio.VT_KEY_HOME,
+	'F': sio.VT_KEY_END,
+	'P': sio.VT_KEY_F1 + 0,
+	'Q': sio.VT_KEY_F1 + 1,
+	'R': sio.VT_KEY_F1 + 2,
+	'S': sio.VT_KEY_F1 + 3,
+}
+
+// vtTildeKeys maps 'ESC [ <number> ~' sequences to VT keycodes.
+var vtTildeKeys = map[int]uint8{
+	1:  sio.VT_KEY_HOME,
+	2:  sio.VT_KEY_INSERT,
+	3:  sio.VT_KEY_DELETE,
+	4:  sio.VT_KEY_END,
+	5:  sio.VT_KEY_PAGE_UP,
+	6:  sio.VT_KEY_PAGE_DOWN,
+	7:  sio.VT_KEY_HOME,
+	8:  sio.VT_KEY_END,
+	11: sio.VT_KEY_F1 + 0,
+	12: sio.VT_KEY_F1 + 1,
+	13: sio.VT_KEY_F1 + 2,
+	14: sio.VT_KEY_F1 + 3,
+	15: sio.VT_KEY_F1 + 4,
+	17: sio.VT_KEY_F1 + 5,
+	18: sio.VT_KEY_F1 + 6,
+	19: sio.VT_KEY_F1 + 7,
+	20: sio.VT_KEY_F1 + 8,
+	21: sio.VT_KEY_F1 + 9,
+	23: sio.VT_KEY_F1 + 10,
+	24: sio.VT_KEY_F1 + 11,
+}
+
+// vtDecodeKeys translates a chunk of host terminal input into VT keycodes.
+// Returns quit as true if the quit key was seen.
+func vtDecodeKeys(input []byte) (keys []uint8, quit bool) {
+	for len(input) > 0 {
+		key := input[0]
+		switch {
+		case key == vtQuitKey:
+			quit = true
+			return
+		case key == 0x1b && len(input) >= 3 && (input[1] == '[' || input[1] == 'O'):
+			// Escape sequence.
+			n := 2
+			number := 0
+			for n < len(input) && input[n] >= '0' && input[n] <= '9' {
+				number = number*10 + int(input[n]-'0')
+				n++
+			}
+			// Skip any modifiers (ie ESC [ 1 ; 5 A)
+			for n < len(input) && (input[n] == ';' || (input[n] >= '0' && input[n] <= '9')) {
+				n++
+			}
+			if n == len(input) {
+				input = nil
+				continue
+			}
+			final := input[n]
+			if final == '~' {
+				vtkey, ok := vtTildeKeys[number]
+				if ok {
+					keys = append(keys, vtkey)
+				}
+			} else {
+				vtkey, ok := vtCsiKeys[final]
+				if ok {
+					keys = append(keys, vtkey)
+				}
+			}
+			input = input[n+1:]
+		case key == '\r', key == '\n':
+			keys = append(keys, sio.VT_KEY_ENTER)
+			input = input[1:]
+		case key == 0x7f:
+			// Most host terminals send DEL for the backspace key.
+			keys = append(keys, sio.VT_KEY_BACKSPACE)
+			input = input[1:]
+		case key < 0x80:
+			keys = append(keys, key)
+			input = input[1:]
+		default:
+			// UTF-8 sequence; only Latin-1 runes have a keycode.
+			r, size := utf8.DecodeRune(input)
+			if r != utf8.RuneError && r < 0x100 {
+				keys = append(keys, uint8(r))
+			}
+			input = input[size:]
+		}
+	}
+
+	return
+}
+
+// vtAnsiColor returns the ANSI SGR parameter for a 16-color index.
+func vtAnsiColor(color uint8, background bool) int {
+	base := 30
+	if color >= 8 {
+		base = 90
+		color -= 8
+	}
+	if background {
+		base += 10
+	}
+	return base + int(color)
+}
+
+// vtAnsiAttr returns the ANSI SGR sequence for the attributes of a cell.
+func vtAnsiAttr(cell sio.VtCell) (sgr string) {
+	sgr = "\033[0"
+	if cell.Bold {
+		sgr += ";1"
+	}
+	if cell.Italic {
+		sgr += ";3"
+	}
+	sgr += fmt.Sprintf(";%d;%dm", vtAnsiColor(cell.Fg, false), vtAnsiColor(cell.Bg, true))
+	return
+}
+
+// vtAnsi renders a VirtualTerminal to an ANSI host terminal.
+type vtAnsi struct {
+	Vt *sio.VirtualTerminal // VT to render.
+
+	out   *bufio.Writer                           // Buffered host terminal output.
+	shown [sio.VT_ROWS][sio.VT_COLUMNS]sio.VtCell // Cells shown on the host terminal.
+	fresh bool                                    // Set to redraw all cells.
+	rows  int                                     // Host terminal rows.
+	cols  int                                     // Host terminal columns.
+
+	state  *term.State    // Host terminal state to restore.
+	done   chan struct{}  // Closed to stop the refresh.
+	quit   chan struct{}  // Closed when the quit key is seen.
+	wg     sync.WaitGroup // Refresh goroutine.
+	closed bool
+}
+
+// Open puts the host terminal into raw mode, starts the keyboard reader,
+// and starts the screen refresh.
+func (va *vtAnsi) Open() (err error) {
+	fd := int(os.Stdin.Fd())
+	va.state, err = term.MakeRaw(fd)
+	if err != nil {
+		return
+	}
+
+	va.cols, va.rows, err = term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || va.cols == 0 || va.rows == 0 {
+		va.cols = sio.VT_COLUMNS
+		va.rows = sio.VT_ROWS
+		err = nil
+	}
+
+	va.out = bufio.NewWriter(os.Stdout)
+	va.fresh = true
+	va.done = make(chan struct{})
+	va.quit = make(chan struct{})
+
+	// Hide cursor, clear screen.
+	va.out.WriteString("\033[?25l\033[2J")
+
+	go va.readKeys()
+
+	va.wg.Add(1)
+	go func() {
+		defer va.wg.Done()
+		ticker := time.NewTicker(vtRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-va.done:
+				return
+			case <-ticker.C:
+				va.Render()
+			}
+		}
+	}()
+
+	return
+}
+
+// readKeys forwards host keystrokes to the VT key queue.
+func (va *vtAnsi) readKeys() {
+	var buff [64]byte
+	for {
+		n, err := os.Stdin.Read(buff[:])
+		if err != nil {
+			return
+		}
+		keys, quit := vtDecodeKeys(buff[:n])
+		va.Vt.PushKey(keys...)
+		if quit {
+			close(va.quit)
+			return
+		}
+	}
+}
+
+// Quitting returns true if the quit key has been pressed.
+func (va *vtAnsi) Quitting() bool {
+	select {
+	case <-va.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Render updates the host terminal with the cells that changed since
+// the last render.
+func (va *vtAnsi) Render() {
+	frame := va.Vt.Frame()
+
+	last := sio.VtCell{}
+	lastValid := false
+	cursorRow, cursorCol := -1, -1
+	for row := range min(va.rows, sio.VT_ROWS) {
+		for col := range min(va.cols, sio.VT_COLUMNS) {
+			cell := frame[row][col]
+			if !va.fresh && cell == va.shown[row][col] {
+				continue
+			}
+			va.shown[row][col] = cell
+			if row != cursorRow || col != cursorCol {
+				fmt.Fprintf(va.out, "\033[%d;%dH", row+1, col+1)
+			}
+			attr := cell
+			attr.Glyph = 0
+			if !lastValid || attr != last {
+				va.out.WriteString(vtAnsiAttr(cell))
+				last = attr
+				lastValid = true
+			}
+			va.out.WriteRune(cell.Rune())
+			cursorRow, cursorCol = row, col+1
+		}
+	}
+	va.fresh = false
+
+	if lastValid {
+		va.out.WriteString("\033[0m")
+	}
+	va.out.Flush()
+}
+
+// Close stops the screen refresh, renders the final screen, and restores
+// the host terminal.
+func (va *vtAnsi) Close() (err error) {
+	if va.closed {
+		return
+	}
+	va.closed = true
+
+	close(va.done)
+	va.wg.Wait()
+
+	va.Render()
+
+	// Show cursor, move below the VT screen.
+	fmt.Fprintf(va.out, "\033[0m\033[?25h\033[%d;1H\r\n", min(va.rows, sio.VT_ROWS))
+	va.out.Flush()
+
+	err = term.Restore(int(os.Stdin.Fd()), va.state)
+	return
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/sio"
+)
+
+func TestVtDecodeKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	table := []struct {
+		Input string
+		Keys  []uint8
+		Quit  bool
+	}{
+		{Input: "abc", Keys: []uint8{'a', 'b', 'c'}},
+		{Input: "\x1b[A\x1b[B\x1b[C\x1b[D", Keys: []uint8{sio.VT_KEY_UP, sio.VT_KEY_DOWN, sio.VT_KEY_RIGHT, sio.VT_KEY_LEFT}},
+		{Input: "\x1bOH\x1bOF\x1bOP\x1bOS", Keys: []uint8{sio.VT_KEY_HOME, sio.VT_KEY_END, sio.VT_KEY_F1, sio.VT_KEY_F1 + 3}},
+		{Input: "\x1b[1;5C", Keys: []uint8{sio.VT_KEY_RIGHT}},
+		{Input: "\x1b[2~\x1b[3~\x1b[5~\x1b[6~", Keys: []uint8{sio.VT_KEY_INSERT, sio.VT_KEY_DELETE, sio.VT_KEY_PAGE_UP, sio.VT_KEY_PAGE_DOWN}},
+		{Input: "\x1b[15~\x1b[24~\x1b[3;2~", Keys: []uint8{sio.VT_KEY_F1 + 4, sio.VT_KEY_F1 + 11, sio.VT_KEY_DELETE}},
+		{Input: "\x1b[99~x\x1b[Zy", Keys: []uint8{'x', 'y'}},
+		{Input: "a\x1b[12", Keys: []uint8{'a'}},
+		{Input: "\x1b[", Keys: []uint8{0x1b, '['}},
+		{Input: "\r\n\x7f", Keys: []uint8{sio.VT_KEY_ENTER, sio.VT_KEY_ENTER, sio.VT_KEY_BACKSPACE}},
+		{Input: "é€ü", Keys: []uint8{0xe9, 0xfc}},
+		{Input: "\x1d", Quit: true},
+		{Input: "ab\x1dcd", Keys: []uint8{'a', 'b'}, Quit: true},
+		{Input: "\x1b[A\x1d\x1b[B", Keys: []uint8{sio.VT_KEY_UP}, Quit: true},
+	}
+
+	for _, entry := range table {
+		keys, quit := vtDecodeKeys([]byte(entry.Input))
+		assert.Equal(entry.Keys, keys, "%q", entry.Input)
+		assert.Equal(entry.Quit, quit, "%q", entry.Input)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+const (
+	ARITH_ADD  = uint32(0 << 29) // A += B
+	ARITH_SUB  = uint32(1 << 29) // A -= B
+	ARITH_ADDC = uint32(2 << 29) // A += constant
+	ARITH_GT   = uint32(3 << 29) // Keep tagged only if A > B
+	ARITH_LT   = uint32(4 << 29) // Keep tagged only if A < B
+
+	ARITH_OP_MASK     = uint32(7 << 29)   // Mask of the operation.
+	ARITH_WIDTH_SHIFT = 24                // Shift of the field width, minus one.
+	ARITH_A_SHIFT     = 19                // Shift of the A field position.
+	ARITH_B_SHIFT     = 14                // Shift of the B field position.
+	ARITH_CONST_MASK  = uint32(1<<19 - 1) // Mask of the ARITH_ADDC constant.
+)
+
+var _arith_defines = map[string]string{
+	"ARITH_ADD":         fmt.Sprintf("0x%x", ARITH_ADD),
+	"ARITH_SUB":         fmt.Sprintf("0x%x", ARITH_SUB),
+	"ARITH_ADDC":        fmt.Sprintf("0x%x", ARITH_ADDC),
+	"ARITH_GT":          fmt.Sprintf("0x%x", ARITH_GT),
+	"ARITH_LT":          fmt.Sprintf("0x%x", ARITH_LT),
+	"ARITH_OP_MASK":     fmt.Sprintf("0x%x", ARITH_OP_MASK),
+	"ARITH_WIDTH_SHIFT": fmt.Sprintf("%d", ARITH_WIDTH_SHIFT),
+	"ARITH_A_SHIFT":     fmt.Sprintf("%d", ARITH_A_SHIFT),
+	"ARITH_B_SHIFT":     fmt.Sprintf("%d", ARITH_B_SHIFT),
+	"ARITH_CONST_MASK":  fmt.Sprintf("0x%x", ARITH_CONST_MASK),
+}
+
+// MakeArithArg encodes the argument of an Arith operation on fields of
+// width bits at positions a and b. For ARITH_ADDC, b is the constant.
+func MakeArithArg(op uint32, width uint, a uint, b uint32) uint32 {
+	arg := op | uint32((width-1)&0x1f)<<ARITH_WIDTH_SHIFT | uint32(a&0x1f)<<ARITH_A_SHIFT
+	if op == ARITH_ADDC {
+		return arg | (b & ARITH_CONST_MASK)
+	}
+	return arg | (b&0x1f)<<ARITH_B_SHIFT
+}
+
+// Arith performs bit-serial arithmetic between two fields, A and B, of
+// every active cell in parallel.
+//
+// The condition flag is set by ARITH_ADD and ARITH_ADDC if any cell
+// carried out of the A field, by ARITH_SUB if any cell borrowed, and by
+// ARITH_GT and ARITH_LT if any cells remain active.
+type Arith struct {
+}
+
+var _ cpu.Coprocessor = (*Arith)(nil)
+
+// Defines returns an iter of defines for the coprocessor.
+func (ar *Arith) Defines() iter.Seq2[string, string] {
+	return maps.All(_arith_defines)
+}
+
+// Execute performs the operation of the argument. The result of each cell
+// is written into the A field. ARITH_ADD, ARITH_SUB and ARITH_ADDC are
+// charged as the bit-serial sequence of a STARAN array: each step writes
+// one bit of the result, and the carry (or borrow) register of the cell,
+// which is clear before the first step. So the CAPP bits flipped are those
+// of the result, and of each change of the carry register. ARITH_GT and
+// ARITH_LT are charged for the tags they drop.
+func (ar *Arith) Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (new_cond bool, err error) {
+	op := arg & ARITH_OP_MASK
+	width := (arg>>ARITH_WIDTH_SHIFT)&0x1f + 1
+	a := (arg >> ARITH_A_SHIFT) & 0x1f
+	b := (arg >> ARITH_B_SHIFT) & 0x1f
+
+	if op > ARITH_LT {
+		err = fmt.Errorf("%w: 0x%x", ErrArithOp, op>>29)
+		return
+	}
+
+	if a+width > 32 || (op != ARITH_ADDC && b+width > 32) {
+		err = fmt.Errorf("%w: %d bits at %d, %d", ErrArithField, width, a, b)
+		return
+	}
+
+	mask := uint32(1<<width - 1)
+	field := func(data uint32, at uint32) uint64 {
+		return uint64((data >> at) & mask)
+	}
+
+	// Carries and borrows, and the changes of the carry registers, are
+	// collected by the (concurrent) updates.
+	var carried atomic.Bool
+	var carries atomic.Int64
+
+	switch op {
+	case ARITH_ADD, ARITH_SUB, ARITH_ADDC:
+		cp.Update(func(data uint32) (uint32, bool) {
+			x := field(data, a)
+			var y, result uint64
+			switch op {
+			case ARITH_ADD:
+				y = field(data, b)
+				result = x + y
+			case ARITH_SUB:
+				y = field(data, b)
+				result = x - y
+			case ARITH_ADDC:
+				y = uint64(arg & ARITH_CONST_MASK & mask)
+				result = x + y
+			}
+			if result > uint64(mask) {
+				carried.Store(true)
+			}
+			// Bit n of carry is the carry (or borrow) into bit n of the
+			// result, so bit 0 is the clear register before the first step.
+			carry := (x ^ y ^ result) & (uint64(mask)<<1 | 1)
+			carries.Add(int64(bits.OnesCount64((carry ^ carry>>1) & uint64(mask))))
+			return (data &^ (mask << a)) | (uint32(result)&mask)<<a, true
+		})
+		cp.AddFlipped(int(carries.Load()))
+		new_cond = carried.Load()
+	case ARITH_GT:
+		cp.Update(func(data uint32) (uint32, bool) {
+			return data, field(data, a) > field(data, b)
+		})
+		new_cond = cp.Count() != 0
+	case ARITH_LT:
+		cp.Update(func(data uint32) (uint32, bool) {
+			return data, field(data, a) < field(data, b)
+		})
+		new_cond = cp.Count() != 0
+	}
+
+	return
+}
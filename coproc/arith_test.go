@@ -0,0 +1,93 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+func TestArith(t *testing.T) {
+	assert := assert.New(t)
+
+	// A field in bits 0..7, B field in bits 8..15.
+	values := []uint32{0x0102, 0x0301, 0xff01, 0x0000, 0x2020}
+
+	table := [](struct {
+		Arg     uint32
+		List    []uint32
+		Cond    bool
+		Flipped int
+	}){
+		{Arg: MakeArithArg(ARITH_ADD, 8, 0, 8), List: []uint32{0x0103, 0x0304, 0xff00, 0x0000, 0x2040}, Cond: true, Flipped: (1 + 0) + (2 + 2) + (1 + 1) + (2 + 2)},
+		{Arg: MakeArithArg(ARITH_SUB, 8, 0, 8), List: []uint32{0x0101, 0x03fe, 0xff02, 0x0000, 0x2000}, Cond: true, Flipped: (2 + 2) + (8 + 1) + (2 + 1) + (1 + 0)},
+		{Arg: MakeArithArg(ARITH_ADDC, 8, 0, 0x11), List: []uint32{0x0113, 0x0312, 0xff12, 0x0011, 0x2031}, Cond: false, Flipped: (2 + 0) + (3 + 2) + (3 + 2) + (2 + 0) + (2 + 0)},
+		{Arg: MakeArithArg(ARITH_ADDC, 4, 4, 0x1), List: []uint32{0x0112, 0x0311, 0xff11, 0x0010, 0x2030}, Cond: false, Flipped: 5},
+		{Arg: MakeArithArg(ARITH_GT, 8, 0, 8), List: []uint32{0x0102}, Cond: true, Flipped: 4},
+		{Arg: MakeArithArg(ARITH_LT, 8, 0, 8), List: []uint32{0x0301, 0xff01}, Cond: true, Flipped: 3},
+		{Arg: MakeArithArg(ARITH_GT, 8, 8, 0), List: []uint32{0x0301, 0xff01}, Cond: true, Flipped: 3},
+		{Arg: MakeArithArg(ARITH_ADD, 16, 0, 16), List: values, Cond: false, Flipped: 0},
+	}
+
+	ar := &Arith{}
+	for _, entry := range table {
+		cp := newTestCapp(values...)
+		cond, err := ar.Execute(entry.Arg, cp, [6]uint32{}, false)
+		assert.NoError(err)
+		assert.Equal(entry.Cond, cond, "arg 0x%x", entry.Arg)
+		assert.Equal(entry.List, slices.Collect(cp.List), "arg 0x%x", entry.Arg)
+		assert.Equal(entry.Flipped, cp.Flipped(), "arg 0x%x", entry.Arg)
+	}
+}
+
+func TestArith_Sharded(t *testing.T) {
+	assert := assert.New(t)
+
+	values := make([]uint32, 1000)
+	for n := range values {
+		values[n] = uint32(n)<<16 | uint32(n*7)
+	}
+
+	ref := newTestCapp(values...)
+	cp := capp.NewSharded(uint(len(values)), 4, func(count uint) capp.Memory { return capp.NewBitPlane(count) })
+	cp.Import(ref.Cell)
+
+	ar := &Arith{}
+	for _, arg := range []uint32{
+		MakeArithArg(ARITH_ADD, 16, 0, 16),
+		MakeArithArg(ARITH_SUB, 12, 4, 16),
+		MakeArithArg(ARITH_ADDC, 16, 16, 0x7ffff),
+		MakeArithArg(ARITH_LT, 16, 0, 16),
+	} {
+		ref_cond, err := ar.Execute(arg, ref, [6]uint32{}, false)
+		assert.NoError(err)
+		cond, err := ar.Execute(arg, cp, [6]uint32{}, false)
+		assert.NoError(err)
+		assert.Equal(ref_cond, cond)
+		assert.Equal(slices.Collect(ref.List), slices.Collect(cp.List))
+		assert.Equal(ref.Flipped(), cp.Flipped())
+	}
+}
+
+func TestArith_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := newTestCapp(1, 2, 3)
+	ar := &Arith{}
+
+	_, err := ar.Execute(7<<29, cp, [6]uint32{}, false)
+	assert.ErrorIs(err, ErrArithOp)
+
+	_, err = ar.Execute(MakeArithArg(ARITH_ADD, 8, 28, 0), cp, [6]uint32{}, false)
+	assert.ErrorIs(err, ErrArithField)
+
+	_, err = ar.Execute(MakeArithArg(ARITH_GT, 8, 0, 25), cp, [6]uint32{}, false)
+	assert.ErrorIs(err, ErrArithField)
+
+	assert.Equal([]uint32{1, 2, 3}, slices.Collect(cp.List))
+	assert.Equal(0, cp.Flipped())
+}
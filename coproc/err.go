@@ -0,0 +1,26 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"errors"
+
+	"github.com/ezrec/ucapp/translate"
+)
+
+var f = translate.From
+
+// ErrArithOp is returned for an unknown Arith operation.
+var ErrArithOp = errors.New(f("arith operation unknown"))
+
+// ErrArithField is returned when an Arith field does not fit in a cell.
+var ErrArithField = errors.New(f("arith field out of range"))
+
+// ErrCoprocUnknown is returned when no coprocessor is registered by a name.
+var ErrCoprocUnknown = errors.New(f("coproc unknown"))
+
+// ErrCoprocRegistered is returned when a coprocessor name is already registered.
+var ErrCoprocRegistered = errors.New(f("coproc already registered"))
+
+// ErrCoprocBinding is returned when a coprocessor binding is not 'cpN=NAME'.
+var ErrCoprocBinding = errors.New(f("coproc binding invalid"))
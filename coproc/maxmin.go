@@ -0,0 +1,80 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"math/bits"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+const (
+	// MAXMIN_MAX searches for the maximum of the field.
+	MAXMIN_MAX = uint32(0)
+	// MAXMIN_MIN searches for the minimum of the field.
+	MAXMIN_MIN = uint32(1 << 31)
+	// MAXMIN_FIELD_MASK is the mask of the field bits of the argument.
+	MAXMIN_FIELD_MASK = uint32(1<<31 - 1)
+)
+
+var _maxmin_defines = map[string]string{
+	"MAXMIN_MAX":        fmt.Sprintf("0x%x", MAXMIN_MAX),
+	"MAXMIN_MIN":        fmt.Sprintf("0x%x", MAXMIN_MIN),
+	"MAXMIN_FIELD_MASK": fmt.Sprintf("0x%x", MAXMIN_FIELD_MASK),
+}
+
+// MaxMin narrows the active list to the cell(s) holding the maximum (or
+// minimum) value of a field, using Foster's bit-serial extremum search.
+//
+// The argument is the field mask, or'd with MAXMIN_MIN to search for the
+// minimum. The condition flag is set if any cells remain active.
+type MaxMin struct {
+}
+
+var _ cpu.Coprocessor = (*MaxMin)(nil)
+
+// Defines returns an iter of defines for the coprocessor.
+func (mm *MaxMin) Defines() iter.Seq2[string, string] {
+	return maps.All(_maxmin_defines)
+}
+
+// Execute searches the field, from the most significant bit down. For
+// each bit of the field, if any active cell has the bit set (or clear,
+// for the minimum), the active cells without it are dropped with a
+// LIST_ONLY action, so the CAPP bits flipped are those of the search.
+func (mm *MaxMin) Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (new_cond bool, err error) {
+	field := arg & MAXMIN_FIELD_MASK
+
+	for field != 0 {
+		bit := uint32(1) << (31 - bits.LeadingZeros32(field))
+		field &^= bit
+
+		want := bit
+		if arg&MAXMIN_MIN != 0 {
+			want = 0
+		}
+
+		if respond(cp, bit, want) {
+			cp.Action(capp.LIST_ONLY, want, bit)
+		}
+	}
+
+	new_cond = cp.Count() != 0
+	return
+}
+
+// respond returns true if any active cell has the bits in mask equal to
+// match. This is the some/none response line of the CAPP, which flips no
+// bits.
+func respond(cp capp.Memory, mask uint32, match uint32) bool {
+	for data := range cp.List {
+		if data&mask == match {
+			return true
+		}
+	}
+	return false
+}
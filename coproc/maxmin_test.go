@@ -0,0 +1,66 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+// newTestCapp creates a CAPP holding values, with all cells active.
+func newTestCapp(values ...uint32) (cp *capp.Capp) {
+	cp = capp.NewCapp(uint(len(values)))
+	for _, value := range values {
+		cp.Action(capp.WRITE_FIRST, value, 0xffffffff)
+		cp.Action(capp.LIST_NEXT, 0, 0)
+	}
+	cp.Action(capp.LIST_ALL, 0, 0)
+	cp.ClearFlipped()
+	return
+}
+
+func TestMaxMin(t *testing.T) {
+	assert := assert.New(t)
+
+	values := []uint32{5, 9, 3, 9, 0x109, 1, 7, 2}
+
+	table := [](struct {
+		Arg     uint32
+		List    []uint32
+		Flipped int
+	}){
+		{Arg: MAXMIN_MAX | 0xff, List: []uint32{9, 9, 0x109}, Flipped: 5},
+		{Arg: MAXMIN_MIN | 0xff, List: []uint32{1}, Flipped: 7},
+		{Arg: MAXMIN_MAX | 0x1ff, List: []uint32{0x109}, Flipped: 7},
+		{Arg: MAXMIN_MIN | 0x6, List: []uint32{9, 9, 0x109, 1}, Flipped: 4},
+		{Arg: MAXMIN_MAX, List: values, Flipped: 0},
+	}
+
+	mm := &MaxMin{}
+	for _, entry := range table {
+		cp := newTestCapp(values...)
+		cond, err := mm.Execute(entry.Arg, cp, [6]uint32{}, false)
+		assert.NoError(err)
+		assert.True(cond)
+		assert.Equal(entry.List, slices.Collect(cp.List), "arg 0x%x", entry.Arg)
+		assert.Equal(entry.Flipped, cp.Flipped(), "arg 0x%x", entry.Arg)
+	}
+}
+
+func TestMaxMin_Empty(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := newTestCapp(1, 2, 3)
+	cp.Action(capp.LIST_NOT, 0, 0)
+	cp.ClearFlipped()
+
+	cond, err := (&MaxMin{}).Execute(MAXMIN_MAX|0xff, cp, [6]uint32{}, true)
+	assert.NoError(err)
+	assert.False(cond)
+	assert.Equal(uint(0), cp.Count())
+	assert.Equal(0, cp.Flipped())
+}
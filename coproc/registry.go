@@ -0,0 +1,89 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// Factory creates a new coprocessor.
+type Factory func() cpu.Coprocessor
+
+var registry = struct {
+	sync.Mutex
+	factory map[string]Factory
+}{
+	factory: map[string]Factory{
+		"maxmin":  func() cpu.Coprocessor { return &MaxMin{} },
+		"arith":   func() cpu.Coprocessor { return &Arith{} },
+		"ternary": func() cpu.Coprocessor { return &Ternary{} },
+	},
+}
+
+// Register a coprocessor factory by name.
+func Register(name string, factory Factory) (err error) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	_, ok := registry.factory[name]
+	if ok {
+		err = fmt.Errorf("%w: %v", ErrCoprocRegistered, name)
+		return
+	}
+
+	registry.factory[name] = factory
+	return
+}
+
+// Names returns the sorted names of the registered coprocessors.
+func Names() (names []string) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	names = slices.Sorted(maps.Keys(registry.factory))
+	return
+}
+
+// New creates a new coprocessor by name.
+func New(name string) (coproc cpu.Coprocessor, err error) {
+	registry.Lock()
+	factory, ok := registry.factory[name]
+	registry.Unlock()
+
+	if !ok {
+		err = fmt.Errorf("%w: %v", ErrCoprocUnknown, name)
+		return
+	}
+
+	coproc = factory()
+	return
+}
+
+// Bind creates the coprocessors of a list of bindings, as 'cpN=NAME', and
+// attaches them to the CPU.
+func Bind(c *cpu.Cpu, bindings ...string) (err error) {
+	for _, binding := range bindings {
+		slot, name, ok := strings.Cut(binding, "=")
+		id := slices.Index([]string{"cp0", "cp1", "cp2", "cp3"}, slot)
+		if !ok || id < 0 {
+			err = fmt.Errorf("%w: %v", ErrCoprocBinding, binding)
+			return
+		}
+
+		var coproc cpu.Coprocessor
+		coproc, err = New(name)
+		if err != nil {
+			return
+		}
+
+		c.Coproc[id] = coproc
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"iter"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+type userProc struct{}
+
+func (up *userProc) Defines() iter.Seq2[string, string] {
+	return maps.All(map[string]string{"USER_PROC": "0x1"})
+}
+
+func (up *userProc) Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (bool, error) {
+	return true, nil
+}
+
+func TestRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Contains(Names(), "maxmin")
+	assert.Contains(Names(), "arith")
+
+	assert.NoError(Register("user", func() cpu.Coprocessor { return &userProc{} }))
+	assert.ErrorIs(Register("user", func() cpu.Coprocessor { return &userProc{} }), ErrCoprocRegistered)
+	assert.Contains(Names(), "user")
+
+	coproc, err := New("arith")
+	assert.NoError(err)
+	assert.IsType(&Arith{}, coproc)
+
+	_, err = New("missing")
+	assert.ErrorIs(err, ErrCoprocUnknown)
+}
+
+func TestBind(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(Register("bind", func() cpu.Coprocessor { return &userProc{} }))
+
+	c := cpu.NewCpu(64)
+	assert.NoError(Bind(c, "cp1=arith", "cp3=bind"))
+	assert.IsType(&Arith{}, c.Coproc[1])
+	assert.IsType(&userProc{}, c.Coproc[3])
+
+	defines := maps.Collect(c.Defines())
+	assert.Equal("0x1", defines["USER_PROC"])
+	assert.Equal("0x40000000", defines["ARITH_ADDC"])
+	assert.NotContains(defines, "MAXMIN_MIN")
+
+	assert.ErrorIs(Bind(c, "cp4=arith"), ErrCoprocBinding)
+	assert.ErrorIs(Bind(c, "arith"), ErrCoprocBinding)
+	assert.ErrorIs(Bind(c, "cp0=missing"), ErrCoprocUnknown)
+}
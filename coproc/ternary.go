@@ -0,0 +1,56 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+const (
+	// TERNARY_FIRST writes the care mask of the first tagged cell.
+	TERNARY_FIRST = uint32(0)
+	// TERNARY_LIST writes the care mask of all tagged cells.
+	TERNARY_LIST = uint32(1 << 31)
+	// TERNARY_CARE_MASK is the mask of the care bits of the argument.
+	TERNARY_CARE_MASK = uint32(1<<31 - 1)
+)
+
+var _ternary_defines = map[string]string{
+	"TERNARY_FIRST":     fmt.Sprintf("0x%x", TERNARY_FIRST),
+	"TERNARY_LIST":      fmt.Sprintf("0x%x", TERNARY_LIST),
+	"TERNARY_CARE_MASK": fmt.Sprintf("0x%x", TERNARY_CARE_MASK),
+}
+
+// Ternary writes the care masks of ternary CAPP cells. A cell only
+// matches SET_OF and LIST_ONLY on the bits it cares about.
+//
+// The argument is the care mask of bits 0 to 30 (a set bit is cared
+// about), or'd with TERNARY_FIRST or TERNARY_LIST. Bit 31 of a cell is
+// always cared about. The condition flag is set if any cells are active.
+type Ternary struct {
+}
+
+var _ cpu.Coprocessor = (*Ternary)(nil)
+
+// Defines returns an iter of defines for the coprocessor.
+func (tc *Ternary) Defines() iter.Seq2[string, string] {
+	return maps.All(_ternary_defines)
+}
+
+// Execute writes the care mask of the first, or all, tagged cells.
+func (tc *Ternary) Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (new_cond bool, err error) {
+	action := capp.CARE_FIRST
+	if arg&TERNARY_LIST != 0 {
+		action = capp.CARE_LIST
+	}
+
+	cp.Action(action, arg, TERNARY_CARE_MASK)
+
+	new_cond = cp.Count() != 0
+	return
+}
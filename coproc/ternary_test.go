@@ -0,0 +1,89 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package coproc
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/emulator"
+)
+
+func TestTernary(t *testing.T) {
+	assert := assert.New(t)
+
+	cp := newTestCapp(0x1234, 0x1200, 0x1000)
+	tc := &Ternary{}
+
+	// Care about all bits of all cells, then prefixes of the last two.
+	cond, err := tc.Execute(TERNARY_LIST|TERNARY_CARE_MASK, cp, [6]uint32{}, false)
+	assert.NoError(err)
+	assert.True(cond)
+	assert.Equal(0, cp.Flipped())
+
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	_, err = tc.Execute(TERNARY_FIRST|0x7fffff00, cp, [6]uint32{}, false)
+	assert.NoError(err)
+	assert.Equal(1+8, cp.Flipped(), "LIST_NEXT, and 8 care bits")
+
+	cp.Action(capp.LIST_NEXT, 0, 0)
+	_, err = tc.Execute(TERNARY_FIRST|0x7ffff000, cp, [6]uint32{}, false)
+	assert.NoError(err)
+
+	cp.Action(capp.SET_OF, 0x12ff, 0xffff)
+	cp.Action(capp.LIST_ALL, 0, 0)
+	assert.Equal([]uint32{0x1200, 0x1000}, slices.Collect(cp.List))
+
+	// Bit 31 is always cared about.
+	cp.Action(capp.SET_OF, 0x80001000, 0x8000ffff)
+	assert.Equal(uint(0), cp.Count())
+
+	cond, err = tc.Execute(TERNARY_FIRST, cp, [6]uint32{}, true)
+	assert.NoError(err)
+	assert.False(cond)
+}
+
+func TestTernary_Lpm(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := emulator.NewEmulator()
+	defer emu.Close()
+	assert.NoError(Bind(emu.Cpu, "cp0=ternary"))
+
+	source, err := os.Open("../examples/tcam/lpm.uc")
+	if !assert.NoError(err) {
+		return
+	}
+	defer source.Close()
+
+	asm := &cpu.Assembler{}
+	for define, value := range emu.Defines() {
+		asm.Predefine(define, value)
+	}
+	asm.Clear()
+	assert.NoError(asm.Parse(source))
+	emu.Program, err = asm.Link()
+	if !assert.NoError(err) {
+		return
+	}
+
+	emu.Tape.Input = bytes.NewReader([]byte{0x34, 0x12, 0x3f, 0x12, 0x99, 0x12, 0x00, 0x1f, 0x00, 0x20})
+	output := &bytes.Buffer{}
+	emu.Tape.Output = output
+
+	assert.NoError(emu.Reset(cpu.CHANNEL_ID_MONITOR))
+	for range 10000 {
+		done, err := emu.Tick()
+		if !assert.NoError(err) || done {
+			break
+		}
+	}
+
+	assert.Equal([]byte{1, 2, 3, 4, 0xff}, output.Bytes())
+}
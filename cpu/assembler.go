@@ -27,6 +27,12 @@ type Macro struct {
 	Lines    []string // Lines of macro text to expand.
 }
 
+// Location is a location in the assembly source.
+type Location struct {
+	Filename string // File name of the source.
+	LineNo   int    // Line number of the source.
+}
+
 // Predefined system equates
 var sysEquate = map[string]string{
 	"LINENO":     "0",
@@ -51,6 +57,22 @@ type Assembler struct {
 	Equate map[string]string   // Map of equates.
 	Macro  map[string](*Macro) // Map of macros.
 
+	LabelSource  map[string]Location // Map of jump labels to the source of their definitions.
+	EquateSource map[string]Location // Map of equates to the source of their definitions.
+
+	expanding []string         // Stack of macro invocations being expanded.
+	expansion map[int][]string // Map of IPs to macro invocations, innermost first.
+
+	globalLabel  string                         // Last global label, the scope of local labels.
+	labelScope   []string                       // Stack of macro expansion local label scopes.
+	expansions   int                            // Number of macro expansions.
+	numericLabel map[string][]numericDefinition // Map of numeric labels to their definitions.
+
+	diagnostics []error // Errors of the assembly, in source order.
+
+	conditional     []conditional // Stack of .if blocks being assembled.
+	conditionalBase int           // Depth of the .if blocks outside the current file or macro.
+
 	FS fs.FS // Filesystem for includes
 }
 
@@ -147,6 +169,7 @@ func (asm *Assembler) irOrImm(words ...string) (ir CodeIR, imms []uint16, err er
 
 	value, err := asm.valueOf(word)
 	if err != nil {
+		err = unknownWord(err, word, maps.Keys(irMap), maps.Keys(asm.Equate))
 		return
 	}
 
@@ -193,6 +216,13 @@ func (asm *Assembler) parenEval(expr string) (value uint32, err error) {
 		err = ErrParseExpression(expr)
 		return
 	}
+	if st_bool, ok := st_rc.(starlark.Bool); ok {
+		// Comparisons are 1 if true, 0 if false.
+		if st_bool {
+			value = 1
+		}
+		return
+	}
 	st_int, ok := st_rc.(starlark.Int)
 	if !ok {
 		err = ErrParseExpression(expr)
@@ -249,7 +279,7 @@ func (asm *Assembler) parseLine(line string, filename string, lineno int) (words
 		return
 	}
 
-	words = slices.DeleteFunc(strings.Split(line, " "), func(a string) bool { return len(a) == 0 })
+	words = splitWords(line)
 
 	if len(words) == 0 {
 		return
@@ -267,6 +297,10 @@ func (asm *Assembler) parseLine(line string, filename string, lineno int) (words
 			return
 		}
 		asm.Equate[words[1]] = words[2]
+		if asm.EquateSource == nil {
+			asm.EquateSource = make(map[string]Location)
+		}
+		asm.EquateSource[words[1]] = Location{Filename: filename, LineNo: lineno}
 		words = words[:0]
 		return
 	}
@@ -284,17 +318,10 @@ func (asm *Assembler) parseLine(line string, filename string, lineno int) (words
 	}
 
 	for strings.HasSuffix(words[0], ":") {
-		label := words[0][:len(words[0])-1]
-		_, ok := asm.Label[label]
-		if ok {
-			err = ErrLabelDuplicate
+		err = asm.defineLabel(words[0][:len(words[0])-1], Location{Filename: filename, LineNo: lineno})
+		if err != nil {
 			return
 		}
-
-		if asm.Label == nil {
-			asm.Label = make(map[string]int, 16)
-		}
-		asm.Label[label] = asm.currentIp()
 		words = words[1:]
 		if len(words) == 0 {
 			return
@@ -318,30 +345,44 @@ func (asm *Assembler) parseLine(line string, filename string, lineno int) (words
 		}
 		defer func() { asm.Equate = old_equate }()
 
+		asm.expanding = append(asm.expanding, fmt.Sprintf("%v %v:%d", name, filename, lineno))
+		defer func() { asm.expanding = asm.expanding[:len(asm.expanding)-1] }()
+
 		defer func() {
 			if err != nil {
 				err = &ErrSyntax{Filename: filename, LineNo: lineno, Line: line, Err: err}
 			}
 		}()
 
+		defer asm.conditionalBlock()()
+		defer asm.enterMacroScope(name)()
+
 		for n, line := range macro.Lines {
 			lineno := macro.LineNo + n
 			filename := macro.Filename
 
 			line = strings.ReplaceAll(line, "@", fmt.Sprintf("%v_%v_", name, lineno))
-			words, err = asm.parseLine(line, filename, lineno)
-			if err != nil {
-				err = &ErrMacro{Macro: name, Filename: filename, LineNo: lineno, Line: line, Err: err}
-				return
-			}
 
-			err = asm.parseWords(words, filename, macro.LineNo+n)
+			var skip bool
+			skip, err = asm.conditionalLine(line)
+			if err == nil && !skip {
+				words, err = asm.parseLine(line, filename, lineno)
+				if err == nil {
+					err = asm.parseWords(words, filename, lineno)
+				}
+			}
 			if err != nil {
 				err = &ErrMacro{Macro: name, Filename: filename, LineNo: lineno, Line: line, Err: err}
 				return
 			}
 		}
 
+		err = asm.conditionalClosed()
+		if err != nil {
+			err = &ErrMacro{Macro: name, Filename: macro.Filename, LineNo: macro.LineNo + len(macro.Lines), Line: ".endm", Err: err}
+			return
+		}
+
 		words = nil
 		return
 	}
@@ -363,12 +404,23 @@ func (asm *Assembler) currentIp() int {
 // Clear the assmbler state, and clears the current program.
 func (asm *Assembler) Clear() {
 	clear(asm.Label)
+	clear(asm.LabelSource)
+	clear(asm.EquateSource)
 	asm.Opcode = asm.Opcode[:0]
 	asm.Data = asm.Data[:0]
 	if asm.Macro == nil {
 		asm.Macro = make(map[string](*Macro))
 	}
 	clear(asm.Macro)
+	clear(asm.expansion)
+	asm.expanding = nil
+	asm.diagnostics = nil
+	asm.conditional = nil
+	asm.conditionalBase = 0
+	asm.globalLabel = ""
+	asm.labelScope = nil
+	asm.expansions = 0
+	clear(asm.numericLabel)
 	asm.Equate = maps.Clone(sysEquate)
 	for attr, val := range asm.predefine {
 		asm.Equate[attr] = val
@@ -392,98 +444,126 @@ type namedReader interface {
 }
 
 // Parse parses an input stream into a Program containing opcodes.
+// All of the errors of the input are returned, as an ErrDiagnostics of
+// ErrSyntax errors, in source order.
 func (asm *Assembler) Parse(input io.Reader) (err error) {
-	var line string
-	var lineno int
-	var macro *Macro
-
 	if !asm.ready {
 		err = ErrAssemblerNotReady
 		return
 	}
 
+	first := len(asm.diagnostics)
+	asm.parse(input)
+
+	if len(asm.diagnostics) != first {
+		// No longer ready for parsing.
+		asm.ready = false
+
+		err = ErrDiagnostics(slices.Clone(asm.diagnostics[first:]))
+		return
+	}
+
+	return
+}
+
+// parse parses an input stream, and records its errors.
+func (asm *Assembler) parse(input io.Reader) {
+	var text string
+	var line string
+	var lineno int
+	var macro *Macro
+
 	filename := "stdin"
 	nr, ok := input.(namedReader)
 	if ok {
 		filename = nr.Name()
 	}
 
-	defer func() {
-		if err != nil {
-			// No longer ready for parsing.
-			asm.ready = false
+	// fail records an error of the current line.
+	fail := func(err error) {
+		asm.diagnostics = append(asm.diagnostics, &ErrSyntax{
+			Filename: filename,
+			LineNo:   lineno,
+			Column:   columnOf(text, err),
+			Line:     line,
+			Err:      err,
+		})
+	}
 
-			err = &ErrSyntax{Filename: filename, LineNo: lineno, Line: line, Err: err}
-		}
-	}()
+	defer asm.conditionalBlock()()
 
 	scanner := bufio.NewScanner(input)
 
 	for scanner.Scan() {
-		text := scanner.Text()
 		lineno += 1
 
 		if asm.Verbose {
-			log.Printf("%v:%v: %v\n", filename, lineno, text)
+			log.Printf("%v:%v: %v\n", filename, lineno, scanner.Text())
 		}
 
-		text_comment := strings.Split(text, ";")
-		line = strings.TrimSpace(text_comment[0])
-		all_words := strings.Split(line, " ")
+		text, _, _ = splitComment(scanner.Text())
+		line = strings.TrimSpace(text)
+		words := splitWords(line)
 
-		var words []string
-		for _, single := range all_words {
-			if len(single) > 0 {
-				words = append(words, single)
+		// .if EXPR, .ifdef NAME, .ifndef NAME, .else, .endif
+		if macro == nil {
+			skip, err := asm.conditionalLine(line)
+			if err != nil {
+				fail(err)
+				continue
+			}
+			if skip {
+				continue
 			}
 		}
 
 		// .include PATH
 		if len(words) > 0 && words[0] == ".include" {
 			if len(words) != 2 {
-				err = ErrIncludePath
-				return
+				fail(ErrIncludePath)
+				continue
 			}
-			var new_in fs.File
-			new_in, err = asm.FS.Open(words[1])
+			new_in, err := asm.FS.Open(words[1])
 			if err != nil {
-				return
+				fail(err)
+				continue
 			}
-			err = asm.Parse(new_in)
+			asm.parse(new_in)
 			new_in.Close()
-			if err != nil {
-				return
-			}
-
 			continue
 		}
 
 		// .macro NAME arg...
 		if len(words) > 0 && words[0] == ".macro" {
 			if macro != nil {
-				err = ErrMacroNesting
-				return
-			}
-			_, ok := asm.Macro[words[1]]
-			if ok {
-				err = ErrMacroDuplicate
-				return
+				fail(ErrMacroNesting)
+				continue
 			}
 			macro = &Macro{
 				Filename: filename,
 				LineNo:   lineno + 1,
 			}
+			if len(words) < 2 {
+				// The lines up to .endm are still the macro.
+				fail(ErrMacroSyntax)
+				continue
+			}
 			if len(words) > 2 {
 				macro.Args = words[2:]
 			}
+			_, ok := asm.Macro[words[1]]
+			if ok {
+				fail(ErrMacroDuplicate)
+				continue
+			}
 			asm.Macro[words[1]] = macro
 			continue
 		}
 
 		if len(words) > 0 && words[0] == ".endm" {
 			if macro == nil {
-				err = ErrMacroLonelyEndm
-				return
+				fail(ErrMacroLonelyEndm)
+				continue
 			}
 			macro = nil
 			continue
@@ -494,28 +574,37 @@ func (asm *Assembler) Parse(input io.Reader) (err error) {
 			continue
 		}
 
-		words, err = asm.parseLine(line, filename, lineno)
+		words, err := asm.parseLine(line, filename, lineno)
 		if err != nil {
-			return
+			fail(err)
+			continue
 		}
 
 		err = asm.parseWords(words, filename, lineno)
 		if err != nil {
-			return
+			fail(err)
+			continue
 		}
 	}
 
-	err = scanner.Err()
+	text, line = "", ""
+
+	err := scanner.Err()
 	if err != nil {
-		return err
+		fail(err)
+		return
 	}
 
 	if macro != nil {
-		err = ErrMacroLonely
+		fail(ErrMacroLonely)
 		return
 	}
 
-	return
+	err = asm.conditionalClosed()
+	if err != nil {
+		fail(err)
+		return
+	}
 }
 
 // Link performs final linkage of the program.
@@ -528,6 +617,7 @@ func (asm *Assembler) Link() (prog *Program, err error) {
 	asm.ready = false
 
 	// Final linking of jump labels.
+	var diagnostics ErrDiagnostics
 	for n := range asm.Opcode {
 		op := &asm.Opcode[n]
 
@@ -535,10 +625,18 @@ func (asm *Assembler) Link() (prog *Program, err error) {
 			continue
 		}
 		label := op.LinkLabel
-		ip, ok := asm.Label[label]
-		if !ok {
-			err = ErrLabelMissing(label)
-			return
+		ip, _, missing := asm.resolveLabel(label, op.Ip)
+		if missing != nil {
+			line := strings.Join(op.Words, " ")
+			missing = unknownWord(missing, label, maps.Keys(asm.Label))
+			diagnostics = append(diagnostics, &ErrSyntax{
+				Filename: op.Filename,
+				LineNo:   op.LineNo,
+				Column:   columnOf(line, missing),
+				Line:     line,
+				Err:      missing,
+			})
+			continue
 		}
 		if len(op.Codes) < 1 {
 			err = fmt.Errorf("unable to link label '%s' to %v:%d: %v", label, op.Filename, op.LineNo, op.Words)
@@ -553,9 +651,25 @@ func (asm *Assembler) Link() (prog *Program, err error) {
 		linked.Immediates[1] |= uint16((ip >> 0) & 0xffff)
 	}
 
+	if len(diagnostics) != 0 {
+		err = diagnostics
+		return
+	}
+
 	prog = &Program{
-		Opcodes: slices.Clone(asm.Opcode),
-		Data:    asm.Data,
+		Opcodes:   slices.Clone(asm.Opcode),
+		Data:      asm.Data,
+		Label:     maps.Clone(asm.Label),
+		Equate:    map[string]string{},
+		Expansion: maps.Clone(asm.expansion),
+	}
+
+	for name, value := range asm.Equate {
+		_, is_sys := sysEquate[name]
+		_, is_predefine := asm.predefine[name]
+		if !is_sys && !is_predefine {
+			prog.Equate[name] = value
+		}
 	}
 
 	return
@@ -588,7 +702,7 @@ func (asm *Assembler) getCoproc(word string) (id CodeCoprocId, err error) {
 		return
 	}
 
-	err = ErrCoprocInvalid
+	err = unknownWord(ErrCoprocInvalid, word, maps.Keys(coprocMap))
 	return
 }
 
@@ -609,6 +723,7 @@ func (asm *Assembler) getChannel(word string) (channel CodeChannel, err error) {
 	}
 	value, err := asm.valueOf(word)
 	if err != nil {
+		err = unknownWord(ErrChannelInvalid, word, maps.Keys(channelMap))
 		return
 	}
 
@@ -671,8 +786,21 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 		}
 		opcode := Opcode{Filename: filename, LineNo: lineno, Ip: asm.currentIp(), Words: initial_words, Codes: codes, LinkLabel: label}
 		asm.Opcode = append(asm.Opcode, opcode)
+		if len(asm.expanding) != 0 {
+			if asm.expansion == nil {
+				asm.expansion = make(map[int][]string)
+			}
+			expansion := slices.Clone(asm.expanding)
+			slices.Reverse(expansion)
+			asm.expansion[opcode.Ip] = expansion
+		}
 	}()
 
+	words = canonicalWords(words, func(word string) bool {
+		_, ok := dstMap[word]
+		return ok
+	})
+
 	cond := COND_ALWAYS
 
 	switch words[0] {
@@ -684,24 +812,13 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 		words = words[1:]
 	}
 
-	var word_is_dst bool
-	if len(words) >= 2 {
-		_, word_is_dst = dstMap[words[1]]
+	if len(words) == 0 {
+		err = ErrOpcodeMissing
+		return
 	}
 
 	// Alternate syntax substitutions
 	switch {
-	case len(words) >= 2 && words[0] == "write" && words[1] == "list":
-		// write list VALUE MASK => list write VALUE MASK
-		words[0] = "list"
-		words[1] = "write"
-	case len(words) >= 2 && words[0] == "write" && words[1] == "first":
-		// write first VALUE MASK => list first VALUE MASK
-		words[0] = "list"
-		words[1] = "first"
-	case len(words) >= 2 && words[0] == "write" && word_is_dst:
-		// write <dst> VALUE MASK => alu set <dst> VALUE MASK
-		words = append([]string{"alu", "set"}, words[1:]...)
 	case len(words) == 2 && words[0] == "if" && words[1] == "some?":
 		// if some? => if gt? count 0
 		words = []string{"if", "gt?", "count", "0"}
@@ -714,17 +831,6 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 	case len(words) == 3 && words[0] == "if" && words[1] == "false?":
 		// if false? SRCA => if eq? SRCA 0
 		words = []string{"if", "eq?", words[2], "0"}
-	case len(words) == 1 && words[0] == "trap":
-		// trap -> io await monitor
-		words = []string{"io", "await", "monitor"}
-	case len(words) >= 1 && words[0] == "fetch":
-		words = append([]string{"io"}, words...)
-	case len(words) >= 1 && words[0] == "store":
-		words = append([]string{"io"}, words...)
-	case len(words) >= 1 && words[0] == "await":
-		words = append([]string{"io"}, words...)
-	case len(words) >= 1 && words[0] == "alert":
-		words = append([]string{"io"}, words...)
 	case len(words) == 1 && words[0] == "return":
 		words = []string{"alu", "set", "ip", "stack"}
 	case len(words) == 2 && words[0] == "vjump":
@@ -820,7 +926,7 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 		case "gt?":
 			op = COND_OP_GT
 		default:
-			err = ErrOpcodeInvalid
+			err = unknownWord(ErrOpcodeInvalid, words[1], slices.Values(ifNames))
 			return
 		}
 		codes = append(codes, MakeCodeCond(cond, op, a, b, imms...))
@@ -894,7 +1000,7 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 			}
 			codes = append(codes, MakeCodeCapp(cond, CAPP_OP_WRITE_FIRST, match, mask, imms...))
 		default:
-			err = ErrOpcodeInvalid
+			err = unknownWord(ErrOpcodeInvalid, words[1], slices.Values(listNames))
 			return
 		}
 	case "coproc":
@@ -906,6 +1012,9 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 			return
 		}
 		id, err = asm.getCoproc(words[1])
+		if err != nil {
+			return
+		}
 		value, mask, imms, err = asm.getMatchMask(cond, words[2:])
 		if err != nil {
 			return
@@ -941,7 +1050,7 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 			}
 			codes = append(codes, MakeCodeIo(cond, IO_OP_AWAIT, channel, arg, imms...))
 		default:
-			err = ErrOpcodeInvalid
+			err = unknownWord(ErrOpcodeInvalid, words[1], slices.Values(ioNames))
 			return
 		}
 	case "call":
@@ -958,7 +1067,7 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 			MakeCodeAlu(cond, ALU_OP_ADD, IR_STACK, IR_IP),
 			MakeCodeAlu(cond, ALU_OP_SET, IR_IP, IR_IMMEDIATE_32, 0, 0),
 		)
-		label = words[1]
+		label = asm.scopedLabel(words[1])
 	case "vcall":
 		if len(words) < 2 {
 			err = ErrOpcodeMissing
@@ -997,7 +1106,7 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 		codes = append(codes,
 			MakeCodeAlu(cond, ALU_OP_SET, IR_IP, IR_IMMEDIATE_32, 0, 0),
 		)
-		label = words[1]
+		label = asm.scopedLabel(words[1])
 	case "alu":
 		if len(words) < 4 {
 			err = ErrOpcodeMissing
@@ -1009,12 +1118,12 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 		}
 		alu, ok := aluMap[words[1]]
 		if !ok {
-			err = ErrOpcodeInvalid
+			err = unknownWord(ErrOpcodeInvalid, words[1], maps.Keys(aluMap))
 			return
 		}
 		reg, ok := dstMap[words[2]]
 		if !ok {
-			err = ErrTargetInvalid
+			err = unknownWord(ErrTargetInvalid, words[2], maps.Keys(dstMap))
 			return
 		}
 		var arg CodeIR
@@ -1024,8 +1133,16 @@ func (asm *Assembler) parseWords(words []string, filename string, lineno int) (e
 			return
 		}
 		codes = append(codes, MakeCodeAlu(cond, alu, reg, arg, imms...))
+	case "write":
+		// Neither a register, nor 'list' or 'first'.
+		if len(words) < 2 {
+			err = ErrOpcodeMissing
+			return
+		}
+		err = unknownWord(ErrTargetInvalid, words[1], maps.Keys(dstMap), slices.Values([]string{"list", "first"}))
+		return
 	default:
-		err = ErrInstructionInvalid
+		err = unknownWord(ErrInstructionInvalid, words[0], slices.Values(instructionNames), maps.Keys(asm.Macro))
 		return
 	}
 
@@ -0,0 +1,163 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// doConditionalParse assembles a program, and returns the words of its opcodes.
+func doConditionalParse(asm *Assembler, program ...string) (words []string, err error) {
+	asm.Clear()
+	err = asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	if err != nil {
+		return
+	}
+
+	prog, err := asm.Link()
+	if err != nil {
+		return
+	}
+
+	for _, op := range prog.Opcodes {
+		if len(op.Codes) != 0 {
+			words = append(words, strings.Join(op.Words, " "))
+		}
+	}
+	return
+}
+
+func TestAssemblerConditional(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Predefine("CAPP_SIZE", "8192")
+
+	table := [](struct {
+		Program []string
+		Words   []string
+	}){
+		{
+			Program: []string{".if CAPP_SIZE >= 4096", "write r0 1", ".else", "write r0 2", ".endif"},
+			Words:   []string{"write r0 1"},
+		},
+		{
+			Program: []string{".if CAPP_SIZE < 4096", "write r0 1", ".else", "write r0 2", ".endif", "write r1 3"},
+			Words:   []string{"write r0 2", "write r1 3"},
+		},
+		{
+			Program: []string{".ifdef CAPP_SIZE", "write r0 1", ".endif", ".ifndef CAPP_SIZE", "write r0 2", ".endif"},
+			Words:   []string{"write r0 1"},
+		},
+		{
+			// Skipped blocks are not evaluated, and nest.
+			Program: []string{
+				".ifdef MISSING",
+				".if MISSING > 1",
+				"write r0 1",
+				".else",
+				"write r0 2",
+				".endif",
+				".else",
+				".ifndef MISSING",
+				"write r0 3",
+				".endif",
+				".endif",
+			},
+			Words: []string{"write r0 3"},
+		},
+		{
+			// Equates and macros are only defined in assembled blocks.
+			Program: []string{
+				".if 0",
+				".equ VALUE 1",
+				".macro SET",
+				"write r0 VALUE",
+				".endm",
+				".else",
+				".equ VALUE 2",
+				".macro SET",
+				"write r1 VALUE",
+				".endm",
+				".endif",
+				"SET",
+			},
+			Words: []string{"write r1 2"},
+		},
+		{
+			// Macro arguments are visible to the conditions of the macro.
+			Program: []string{
+				".macro SET REG N",
+				".if N > 2",
+				"write REG N",
+				".else",
+				"write REG 0",
+				".endif",
+				".endm",
+				"SET r1 3",
+				"SET r2 1",
+			},
+			Words: []string{"write r1 3", "write r2 0"},
+		},
+	}
+
+	for n, entry := range table {
+		words, err := doConditionalParse(asm, entry.Program...)
+		assert.NoError(err, "program %d", n)
+		assert.Equal(entry.Words, words, "program %d", n)
+	}
+}
+
+func TestAssemblerConditional_Include(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.FS = fstest.MapFS{
+		"once.inc":  &fstest.MapFile{Data: []byte(".ifndef ONCE\n.equ ONCE 1\nwrite r1 1\n.endif\n")},
+		"open.inc":  &fstest.MapFile{Data: []byte(".if 1\nwrite r1 1\n")},
+		"close.inc": &fstest.MapFile{Data: []byte("write r1 1\n.endif\n")},
+	}
+
+	words, err := doConditionalParse(asm, ".include once.inc", ".include once.inc", "write r0 0")
+	assert.NoError(err)
+	assert.Equal([]string{"write r1 1", "write r0 0"}, words)
+
+	_, err = doConditionalParse(asm, ".if 1", ".include open.inc", ".endif")
+	assert.ErrorIs(err, ErrIfLonely)
+
+	_, err = doConditionalParse(asm, ".if 1", ".include close.inc")
+	assert.ErrorIs(err, ErrIfLonelyEndif)
+}
+
+func TestAssemblerConditional_Errors(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+
+	table := [](struct {
+		Program []string
+		Err     error
+	}){
+		{Program: []string{".if"}, Err: ErrIfSyntax},
+		{Program: []string{".ifdef"}, Err: ErrIfSyntax},
+		{Program: []string{".ifndef A B"}, Err: ErrIfSyntax},
+		{Program: []string{".if 1"}, Err: ErrIfLonely},
+		{Program: []string{".else"}, Err: ErrIfLonelyElse},
+		{Program: []string{".endif"}, Err: ErrIfLonelyEndif},
+		{Program: []string{".if 1", ".else", ".else", ".endif"}, Err: ErrIfElseDuplicate},
+		{Program: []string{".if", ".endif"}, Err: ErrIfSyntax},
+		{Program: []string{".macro OPEN", ".if 1", ".endm", "OPEN", ".endif"}, Err: ErrIfLonely},
+		{Program: []string{".macro CLOSE", ".endif", ".endm", ".if 1", "CLOSE", ".endif"}, Err: ErrIfLonelyEndif},
+	}
+
+	for n, entry := range table {
+		_, err := doConditionalParse(asm, entry.Program...)
+		assert.ErrorIs(err, entry.Err, "program %d", n)
+	}
+
+	// Conditions must evaluate to integers.
+	_, err := doConditionalParse(asm, ".if MISSING", ".endif")
+	assert.Error(err)
+}
@@ -0,0 +1,131 @@
+package cpu
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssemblerDiagnostics(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+
+	program := []string{
+		".macro SETX REG",
+		"alu sett REG 1",
+		".endm",
+		"Main:",
+		"    write r9 1 ; bad target",
+		"    list alll",
+		"    io fetch tap",
+		"    SETX r0",
+		"    jmp Main",
+		"    coproc cp9 1",
+		".if",
+		"write r0 1",
+		".endif",
+		".macro",
+	}
+
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+
+	var diags ErrDiagnostics
+	if !assert.True(errors.As(err, &diags)) {
+		return
+	}
+	assert.Len(diags, 9)
+	assert.ErrorIs(err, ErrTargetInvalid)
+	assert.ErrorIs(err, ErrOpcodeInvalid)
+	assert.ErrorIs(err, ErrChannelInvalid)
+	assert.ErrorIs(err, ErrInstructionInvalid)
+	assert.ErrorIs(err, ErrCoprocInvalid)
+	assert.ErrorIs(err, ErrIfSyntax)
+	assert.ErrorIs(err, ErrMacroSyntax)
+	assert.ErrorIs(err, ErrMacroLonely)
+
+	// The first error is still available with errors.As.
+	var se *ErrSyntax
+	assert.True(errors.As(err, &se))
+	assert.Equal(5, se.LineNo)
+	assert.Equal(11, se.Column)
+
+	table := []Diagnostic{
+		{Filename: "stdin", LineNo: 5, Column: 11, Suggest: []string{"r0", "r1", "r2"}},
+		{Filename: "stdin", LineNo: 6, Column: 10, Suggest: []string{"all"}},
+		{Filename: "stdin", LineNo: 7, Column: 14, Suggest: []string{"tape"}},
+		{Filename: "stdin", LineNo: 8, Macro: []string{"SETX stdin:2"}, Suggest: []string{"set"}},
+		{Filename: "stdin", LineNo: 9, Column: 5, Suggest: []string{"jump"}},
+		{Filename: "stdin", LineNo: 10, Column: 12, Suggest: []string{"cp0", "cp1", "cp2"}},
+		{Filename: "stdin", LineNo: 11},
+		{Filename: "stdin", LineNo: 14},
+		{Filename: "stdin", LineNo: 14},
+	}
+
+	diagnostics := Diagnostics(err)
+	if assert.Len(diagnostics, len(table)) {
+		for n, diagnostic := range diagnostics {
+			assert.NotEmpty(diagnostic.Message, "diagnostic %d", n)
+			diagnostic.Message = ""
+			assert.Equal(table[n], diagnostic, "diagnostic %d", n)
+		}
+	}
+
+	assert.Equal("stdin:6:10: opcode invalid 'alll' (did you mean 'all'?)", diagnostics[1].String())
+	assert.Equal("stdin:8: opcode invalid 'sett' (did you mean 'set'?) (in SETX stdin:2)", diagnostics[3].String())
+
+	_, err = asm.Link()
+	assert.ErrorIs(err, ErrAssemblerNotReady)
+}
+
+func TestAssemblerDiagnostics_Link(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+
+	err := asm.Parse(strings.NewReader("Main:\njump Mian\ncall Other\njump Main\n"))
+	assert.NoError(err)
+
+	_, err = asm.Link()
+	assert.ErrorIs(err, ErrLabelMissing("Mian"))
+	assert.ErrorIs(err, ErrLabelMissing("Other"))
+
+	diagnostics := Diagnostics(err)
+	if assert.Len(diagnostics, 2) {
+		assert.Equal(Diagnostic{
+			Filename: "stdin",
+			LineNo:   2,
+			Column:   6,
+			Message:  "label Mian missing (did you mean 'Main'?)",
+			Suggest:  []string{"Main"},
+		}, diagnostics[0])
+		assert.Equal(3, diagnostics[1].LineNo)
+		assert.Empty(diagnostics[1].Suggest)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, distance("abc", "abc"))
+	assert.Equal(1, distance("abc", "ab"))
+	assert.Equal(1, distance("abc", "abd"))
+	assert.Equal(3, distance("", "abc"))
+	assert.Equal(2, distance("kitten", "sittin"))
+	assert.Equal(1, distance("fecth", "fetch"))
+
+	known := slices.Values([]string{"fetch", "store", "alert", "await"})
+	assert.Equal([]string{"fetch"}, suggest("fecth", known))
+	assert.Equal([]string{"alert"}, suggest("alart", known))
+	assert.Equal([]string{"alert"}, suggest("ALERT", known))
+	assert.Empty(suggest("xyzzy", known))
+
+	assert.Equal(0, columnOf("write r0 1", ErrTargetInvalid))
+	assert.Equal(7, columnOf("write r9 1", ErrUnknown{Word: "r9", Err: ErrTargetInvalid}))
+	assert.Equal(0, columnOf("write r99 1", ErrUnknown{Word: "r9", Err: ErrTargetInvalid}))
+}
@@ -0,0 +1,144 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// doLabelLink assembles a program, and returns the program and the target
+// IP of each jump or call, by line.
+func doLabelLink(program ...string) (prog *Program, target map[int]int, err error) {
+	asm := &Assembler{}
+	asm.Clear()
+	err = asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	if err != nil {
+		return
+	}
+
+	prog, err = asm.Link()
+	if err != nil {
+		return
+	}
+
+	target = map[int]int{}
+	for _, op := range prog.Opcodes {
+		if len(op.LinkLabel) == 0 {
+			continue
+		}
+		imms := op.Codes[len(op.Codes)-1].Immediates
+		target[op.LineNo] = int(imms[0])<<16 | int(imms[1])
+	}
+	return
+}
+
+func TestAssemblerLabel_Local(t *testing.T) {
+	assert := assert.New(t)
+
+	prog, target, err := doLabelLink(
+		".loop:",
+		"Foo:",
+		".loop:",
+		"jump .loop",
+		"jump .done",
+		".done:",
+		"Bar:",
+		".loop:",
+		"jump .loop",
+		"jump .done",
+		".done:",
+		"return",
+	)
+	assert.NoError(err)
+	assert.Equal(0, prog.Label[".loop"])
+	assert.Equal(0, prog.Label["Foo"])
+	assert.Equal(prog.Label["Foo.loop"], target[4])
+	assert.Equal(prog.Label["Foo.done"], target[5])
+	assert.Equal(prog.Label["Bar.loop"], target[9])
+	assert.Equal(prog.Label["Bar.done"], target[10])
+	assert.NotEqual(target[4], target[9])
+	assert.NotEqual(target[5], target[10])
+
+	_, _, err = doLabelLink("Foo:", ".loop:", ".loop:")
+	assert.ErrorIs(err, ErrLabelDuplicate)
+
+	// A local label is not visible after the next global label.
+	_, _, err = doLabelLink("Foo:", "jump .done", "Bar:", ".done:", "return")
+	assert.ErrorIs(err, ErrLabelMissing("Foo.done"))
+}
+
+func TestAssemblerLabel_Macro(t *testing.T) {
+	assert := assert.New(t)
+
+	prog, target, err := doLabelLink(
+		".macro LOOP",
+		".loop:",
+		"alu sub r0 1",
+		"if ne? r0 0",
+		"+ jump .loop",
+		".endm",
+		"Main:",
+		".loop:",
+		"LOOP",
+		"LOOP",
+		"jump .loop",
+	)
+	assert.NoError(err)
+	assert.Equal(prog.Label["Main.loop"], target[11])
+
+	// Each expansion has its own scope, so both loop to themselves.
+	first, ok := prog.Label["LOOP@1.loop"]
+	assert.True(ok)
+	second, ok := prog.Label["LOOP@2.loop"]
+	assert.True(ok)
+	assert.NotEqual(first, second)
+
+	var loops []int
+	for _, op := range prog.Opcodes {
+		if len(op.LinkLabel) != 0 && op.LineNo == 5 {
+			imms := op.Codes[len(op.Codes)-1].Immediates
+			loops = append(loops, int(imms[0])<<16|int(imms[1]))
+		}
+	}
+	assert.Equal([]int{first, second}, loops)
+}
+
+func TestAssemblerLabel_Numeric(t *testing.T) {
+	assert := assert.New(t)
+
+	prog, target, err := doLabelLink(
+		"1:",
+		"write r0 1",
+		"jump 1f",
+		"jump 1b",
+		"1: jump 1b",
+		"jump 1b",
+		"jump 2f",
+		"1:",
+		"2: return",
+	)
+	assert.NoError(err)
+	assert.NotContains(prog.Label, "1")
+
+	ip := func(line int) int {
+		for _, op := range prog.Opcodes {
+			if op.LineNo == line && len(op.Codes) != 0 {
+				return op.Ip
+			}
+		}
+		return -1
+	}
+
+	assert.Equal(ip(5), target[3])
+	assert.Equal(0, target[4])
+	assert.Equal(ip(5), target[5])
+	assert.Equal(ip(5), target[6])
+	assert.Equal(ip(9), target[7])
+
+	_, _, err = doLabelLink("jump 1f", "1:", "jump 1f")
+	assert.ErrorIs(err, ErrLabelMissing("1f"))
+
+	_, _, err = doLabelLink("jump 1b", "1: return")
+	assert.ErrorIs(err, ErrLabelMissing("1b"))
+}
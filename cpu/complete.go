@@ -0,0 +1,70 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// directiveNames are the assembler directives.
+var directiveNames = []string{
+	".equ", ".include", ".macro", ".endm",
+	".if", ".ifdef", ".ifndef", ".else", ".endif",
+}
+
+// Complete returns the sorted words that may follow the words of a line,
+// for the completion of the next word: the instructions and directives,
+// then the actions, registers, channels, coprocessors, labels and equates
+// that the instruction takes.
+func (asm *Assembler) Complete(words []string) (names []string) {
+	for len(words) > 0 && strings.HasSuffix(words[0], ":") {
+		words = words[1:]
+	}
+	if len(words) > 0 && (words[0] == "+" || words[0] == "-") {
+		words = words[1:]
+	}
+
+	values := func() {
+		names = slices.AppendSeq(names, maps.Keys(irMap))
+		names = slices.AppendSeq(names, maps.Keys(asm.Equate))
+	}
+
+	switch {
+	case len(words) == 0:
+		names = append(names, instructionNames...)
+		names = append(names, directiveNames...)
+		names = slices.AppendSeq(names, maps.Keys(asm.Macro))
+	case words[0] == "alu" && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(aluMap))
+	case words[0] == "alu" && len(words) == 2:
+		names = slices.AppendSeq(names, maps.Keys(dstMap))
+	case words[0] == "list" && len(words) == 1:
+		names = append(names, listNames...)
+	case words[0] == "if" && len(words) == 1:
+		names = append(names, ifNames...)
+	case words[0] == "io" && len(words) == 1:
+		names = append(names, ioNames...)
+	case words[0] == "io" && len(words) == 2,
+		slices.Contains(ioNames, words[0]) && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(channelMap))
+	case words[0] == "coproc" && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(coprocMap))
+	case words[0] == "write" && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(dstMap))
+		names = append(names, "first", "list")
+	case (words[0] == "jump" || words[0] == "call") && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(asm.Label))
+	case (words[0] == ".ifdef" || words[0] == ".ifndef") && len(words) == 1:
+		names = slices.AppendSeq(names, maps.Keys(asm.Equate))
+	case strings.HasPrefix(words[0], "."):
+		// Directive arguments are not completed.
+	default:
+		values()
+	}
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+	return
+}
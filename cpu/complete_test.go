@@ -0,0 +1,106 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssemblerComplete(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+
+	program := []string{
+		".equ TAPE_EOF 0x100",
+		".macro SETX REG",
+		"alu set REG 1",
+		".endm",
+		"Main:",
+		"jump Main",
+	}
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+
+	table := []struct {
+		Line     string
+		Contains []string
+		Excludes []string
+	}{
+		{Line: "", Contains: []string{"alu", "list", "io", ".equ", ".ifdef", "SETX"}, Excludes: []string{"r0"}},
+		{Line: "Main: +", Contains: []string{"alu", "exit"}},
+		{Line: "alu", Contains: []string{"add", "set", "shl"}, Excludes: []string{"r0"}},
+		{Line: "alu set", Contains: []string{"r0", "ip", "stack"}, Excludes: []string{"count"}},
+		{Line: "alu set r0", Contains: []string{"r5", "count", "TAPE_EOF"}},
+		{Line: "list", Contains: []string{"all", "only", "write"}},
+		{Line: "if", Contains: []string{"eq?", "some?", "true?"}},
+		{Line: "io", Contains: []string{"fetch", "await"}},
+		{Line: "io fetch", Contains: []string{"tape", "depot", "vt"}},
+		{Line: "store", Contains: []string{"tape", "monitor"}},
+		{Line: "coproc", Contains: []string{"cp0", "cp3"}},
+		{Line: "write", Contains: []string{"r0", "first", "list"}},
+		{Line: "call", Contains: []string{"Main"}, Excludes: []string{"TAPE_EOF"}},
+		{Line: ".ifdef", Contains: []string{"TAPE_EOF", "ARENA_IO"}},
+		{Line: ".include", Excludes: []string{"r0"}},
+	}
+
+	for _, entry := range table {
+		names := asm.Complete(strings.Fields(entry.Line))
+		assert.IsNonDecreasing(names, entry.Line)
+		for _, name := range entry.Contains {
+			assert.Contains(names, name, entry.Line)
+		}
+		for _, name := range entry.Excludes {
+			assert.NotContains(names, name, entry.Line)
+		}
+	}
+}
+
+func TestAssemblerLabelDefinition(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+
+	program := []string{
+		".equ COUNT 3",
+		"Main:",
+		"1:",
+		"  jump 1f",
+		".loop:",
+		"  jump .loop",
+		"1:",
+		"  jump 1b",
+	}
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+
+	assert.Equal(Location{Filename: "stdin", LineNo: 1}, asm.EquateSource["COUNT"])
+	assert.Equal(Location{Filename: "stdin", LineNo: 2}, asm.LabelSource["Main"])
+	assert.Equal(Location{Filename: "stdin", LineNo: 5}, asm.LabelSource["Main.loop"])
+
+	table := []struct {
+		Line   int
+		Source Location
+	}{
+		{Line: 4, Source: Location{Filename: "stdin", LineNo: 7}},
+		{Line: 6, Source: Location{Filename: "stdin", LineNo: 5}},
+		{Line: 8, Source: Location{Filename: "stdin", LineNo: 7}},
+	}
+
+	for _, entry := range table {
+		for _, op := range asm.Opcode {
+			if op.LineNo != entry.Line {
+				continue
+			}
+			source, ok := asm.LabelDefinition(op.LinkLabel, op.Ip)
+			assert.True(ok, "line %d", entry.Line)
+			assert.Equal(entry.Source, source, "line %d", entry.Line)
+		}
+	}
+
+	_, ok := asm.LabelDefinition("Missing", 0)
+	assert.False(ok)
+}
@@ -0,0 +1,130 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"strings"
+)
+
+// conditional is the state of a .if/.ifdef/.ifndef block.
+type conditional struct {
+	parent bool // Set if the lines around the block are assembled.
+	taken  bool // Set if a branch of the block has been assembled.
+	active bool // Set if the lines of the current branch are assembled.
+	elsed  bool // Set after the .else of the block.
+}
+
+// assembling returns true if the current line is not in a skipped branch.
+func (asm *Assembler) assembling() bool {
+	if len(asm.conditional) == 0 {
+		return true
+	}
+
+	return asm.conditional[len(asm.conditional)-1].active
+}
+
+// conditionalBlock starts a file or a macro expansion, whose .if, .ifdef,
+// and .ifndef blocks must be closed by its own .endif lines. Returns the
+// function that ends it.
+func (asm *Assembler) conditionalBlock() (end func()) {
+	base := asm.conditionalBase
+	asm.conditionalBase = len(asm.conditional)
+
+	end = func() {
+		asm.conditional = asm.conditional[:asm.conditionalBase]
+		asm.conditionalBase = base
+	}
+	return
+}
+
+// conditionalClosed returns an error if a block of the current file or
+// macro expansion has no .endif.
+func (asm *Assembler) conditionalClosed() (err error) {
+	if len(asm.conditional) != asm.conditionalBase {
+		err = ErrIfLonely
+	}
+	return
+}
+
+// conditionalLine handles the conditional assembly directives. Returns
+// true if the line is a directive, or is in a skipped branch.
+func (asm *Assembler) conditionalLine(line string) (skip bool, err error) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		skip = !asm.assembling()
+		return
+	}
+
+	skip = true
+	assembling := asm.assembling()
+
+	switch words[0] {
+	case ".if":
+		// .if EXPR
+		if len(words) < 2 {
+			asm.pushInvalidConditional(assembling)
+			err = ErrIfSyntax
+			return
+		}
+		var value uint32
+		if assembling {
+			value, err = asm.parenEval(strings.Join(words[1:], " "))
+			if err != nil {
+				asm.pushInvalidConditional(assembling)
+				return
+			}
+		}
+		asm.pushConditional(assembling, value != 0)
+	case ".ifdef", ".ifndef":
+		// .ifdef NAME, .ifndef NAME
+		if len(words) != 2 {
+			asm.pushInvalidConditional(assembling)
+			err = ErrIfSyntax
+			return
+		}
+		_, defined := asm.Equate[words[1]]
+		asm.pushConditional(assembling, defined == (words[0] == ".ifdef"))
+	case ".else":
+		if len(asm.conditional) == asm.conditionalBase {
+			err = ErrIfLonelyElse
+			return
+		}
+		cond := &asm.conditional[len(asm.conditional)-1]
+		if cond.elsed {
+			err = ErrIfElseDuplicate
+			return
+		}
+		cond.active = cond.parent && !cond.taken
+		cond.taken = true
+		cond.elsed = true
+	case ".endif":
+		if len(asm.conditional) == asm.conditionalBase {
+			err = ErrIfLonelyEndif
+			return
+		}
+		asm.conditional = asm.conditional[:len(asm.conditional)-1]
+	default:
+		skip = !assembling
+	}
+
+	return
+}
+
+// pushConditional starts a conditional block, whose first branch is
+// assembled if the lines around it are, and the condition is true.
+func (asm *Assembler) pushConditional(parent bool, condition bool) {
+	asm.conditional = append(asm.conditional, conditional{
+		parent: parent,
+		taken:  parent && condition,
+		active: parent && condition,
+	})
+}
+
+// pushInvalidConditional starts a conditional block with an invalid
+// condition, none of whose branches are assembled.
+func (asm *Assembler) pushInvalidConditional(parent bool) {
+	asm.conditional = append(asm.conditional, conditional{
+		parent: parent,
+		taken:  true,
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"math/bits"
 
 	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/internal"
 	"github.com/ezrec/ucapp/sio"
 )
 
@@ -40,14 +41,17 @@ type CpuChannel struct {
 // Coprocessor executes on the CAPP, has read-only register access,
 // and may (optionally) modify the condition flag.
 type Coprocessor interface {
-	Execute(arg uint32, cp *capp.Capp, reg [6]uint32, cond bool) (new_cond bool, err error)
+	// Per-coprocessor defines
+	Defines() iter.Seq2[string, string]
+	// Execute the coprocessor with an argument.
+	Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (new_cond bool, err error)
 }
 
 // Cpu is the simulation context for the control CPU attached to the CAPP
 type Cpu struct {
 	Verbose bool // Set to enable verbose logging.
 
-	Capp *capp.Capp // Reference to the CAPP simulation.
+	Capp capp.Memory // Reference to the CAPP simulation.
 
 	Ip       uint32    // Current instruction pointer.
 	Register [6]uint32 // Register bank.
@@ -59,15 +63,28 @@ type Cpu struct {
 	Power int // Power (bits flipped) counter.
 	Ticks int // CPU ticks counter.
 
+	aluFlipped int // ALU bits flipped by the last instruction.
+
+	iram      map[uint16]Code // Decoded code arena, by IP.
+	iramValid bool            // Set when the iram holds the code arena.
+
 	channel [8](*CpuChannel) // IO channels.
 
 	Coproc [4](Coprocessor) // Coprocessors
+
+	Tracer Tracer // If set, records every executed instruction.
+
+	watcher *capp.Watcher // Content watchpoints, if any.
 }
 
 type invalidCoproc struct {
 }
 
-func (ic *invalidCoproc) Execute(arg uint32, cp *capp.Capp, reg [6]uint32, cond bool) (new_cond bool, err error) {
+func (ic *invalidCoproc) Defines() iter.Seq2[string, string] {
+	return maps.All(map[string]string{})
+}
+
+func (ic *invalidCoproc) Execute(arg uint32, cp capp.Memory, reg [6]uint32, cond bool) (new_cond bool, err error) {
 	new_cond = false
 	err = ErrOpcodeCoproc
 	return
@@ -75,8 +92,14 @@ func (ic *invalidCoproc) Execute(arg uint32, cp *capp.Capp, reg [6]uint32, cond
 
 // NewCpu creates a new CPU with a specifically sized CAPP.
 func NewCpu(count uint) (cpu *Cpu) {
+	cpu = NewCpuWithCapp(capp.NewCapp(count))
+	return
+}
+
+// NewCpuWithCapp creates a new CPU attached to a CAPP memory.
+func NewCpuWithCapp(cp capp.Memory) (cpu *Cpu) {
 	cpu = &Cpu{
-		Capp: capp.NewCapp(count),
+		Capp: cp,
 	}
 
 	for n := range 4 {
@@ -86,9 +109,13 @@ func NewCpu(count uint) (cpu *Cpu) {
 	return
 }
 
-// Defines for the cpu
+// Defines for the cpu, and its coprocessors.
 func (cpu *Cpu) Defines() iter.Seq2[string, string] {
-	return maps.All(_cpu_defines)
+	seqs := []iter.Seq2[string, string]{maps.All(_cpu_defines)}
+	for _, coproc := range cpu.Coproc {
+		seqs = append(seqs, coproc.Defines())
+	}
+	return internal.IterSeq2Concat(seqs...)
 }
 
 // Close closes all I/O channels associated with the CPU.
@@ -166,6 +193,7 @@ func (cpu *Cpu) Reset(boot CodeChannel) (err error) {
 	clear(cpu.Register[:])
 	cpu.Stack.Reset()
 	cpu.Capp.Reset()
+	cpu.InvalidateIram()
 	cpu.Ticks = 0
 	cpu.Power = 0
 
@@ -342,28 +370,18 @@ func (cpu *Cpu) FetchCode() (code Code, err error) {
 		}
 		code = Code{Word: uint16(opcode)}
 	case IP_MODE_CAPP:
-		cpu.Capp.Verbose = false
-		cpu.Capp.Action(capp.SET_SWAP, 0, 0)
-		cpu.Capp.Action(capp.SET_OF, ARENA_CODE|(uint32(cpu.Ip&0x3fff)<<16), ARENA_MASK|(0x3fff<<16))
-		cpu.Capp.Action(capp.LIST_ALL, 0, 0)
-
-		count := cpu.Capp.Count()
-		var imms []uint16
-		for count > 1 {
-			// save immediates
-			imms = append(imms, uint16(cpu.Capp.First()&0xffff))
-			cpu.Capp.Action(capp.LIST_NEXT, 0, 0)
-			count = cpu.Capp.Count()
-		}
-		first := cpu.Capp.First()
-		cpu.Capp.Action(capp.SET_SWAP, 0, 0)
-		if count != 1 {
+		if !cpu.iramValid {
+			cpu.LoadIram()
+		}
+		var ok bool
+		code, ok = cpu.iram[uint16(cpu.Ip&0x3fff)]
+		if !ok {
+			if cpu.Verbose {
+				log.Printf("Ip 0x%x > not in iram", cpu.Ip)
+			}
 			err = ErrIpEmpty
 			return
 		}
-		code = Code{Word: uint16(first & 0xffff), Immediates: imms}
-
-		cpu.Capp.Verbose = cpu.Verbose
 	default:
 		if cpu.Verbose {
 			log.Printf("Ip 0x%x > unknown source", cpu.Ip)
@@ -375,21 +393,78 @@ func (cpu *Cpu) FetchCode() (code Code, err error) {
 	return
 }
 
+// LoadIram decodes all of the code arena words in the CAPP into the IRAM,
+// indexed by IP. The words of an IP are its immediates, in CAPP order,
+// followed by its opcode.
+//
+// The IRAM is loaded by the first instruction fetch in IP_MODE_CAPP after
+// a Reset, after the IP leaves IP_MODE_CAPP, or after InvalidateIram.
+// Changes to the code arena of the CAPP do not affect the IRAM until then.
+func (cpu *Cpu) LoadIram() {
+	cp := cpu.Capp
+
+	cp.SetVerbose(false)
+	cp.Action(capp.SET_SWAP, 0, 0)
+	cp.Action(capp.SET_OF, ARENA_CODE, ARENA_MASK)
+	cp.Action(capp.LIST_ALL, 0, 0)
+
+	words := map[uint16][]uint16{}
+	for data := range cp.List {
+		ip := uint16((data >> 16) & 0x3fff)
+		words[ip] = append(words[ip], uint16(data&0xffff))
+	}
+
+	cp.Action(capp.SET_SWAP, 0, 0)
+	cp.SetVerbose(cpu.Verbose)
+
+	cpu.iram = make(map[uint16]Code, len(words))
+	for ip, list := range words {
+		code := Code{Word: list[len(list)-1]}
+		if len(list) > 1 {
+			code.Immediates = list[:len(list)-1]
+		}
+		cpu.iram[ip] = code
+	}
+	cpu.iramValid = true
+
+	if cpu.Verbose {
+		log.Printf("cpu: iram loaded, %d codes", len(cpu.iram))
+	}
+}
+
+// InvalidateIram discards the IRAM, so that it is reloaded from the CAPP
+// by the next instruction fetch in IP_MODE_CAPP.
+func (cpu *Cpu) InvalidateIram() {
+	cpu.iram = nil
+	cpu.iramValid = false
+}
+
 // Tick executes a single CPU instruction cycle.
 func (cpu *Cpu) Tick() (err error) {
 	// Set CAPP verbosity
-	cpu.Capp.Verbose = cpu.Verbose
+	cpu.Capp.SetVerbose(cpu.Verbose)
 
 	code, err := cpu.FetchCode()
 	if err != nil {
 		return
 	}
 
+	// Only the watchpoints triggered by this instruction are reported.
+	if cpu.watcher != nil {
+		cpu.watcher.Events()
+	}
+
 	err = cpu.Execute(code)
 	if err != nil {
 		return
 	}
 
+	// Check for triggered content watchpoints.
+	err = cpu.checkWatch()
+	if err != nil {
+		return
+	}
+
 	// Check for trap on PROGRAM channel.
 	_, trap, err := cpu.GetChannel(CHANNEL_ID_MONITOR)
 	if err == nil {
@@ -417,6 +492,13 @@ func (cpu *Cpu) Execute(code Code) (err error) {
 			err = errors.Join(ErrOpcode(code), err)
 		}
 	}()
+	if cpu.Tracer != nil {
+		before := cpu.TraceState()
+		traced := code
+		defer func() {
+			cpu.Tracer.Trace(cpu.traceEvent(&before, traced, err))
+		}()
+	}
 	if cpu.Verbose {
 		cond := " "
 		if cpu.Cond {
@@ -427,7 +509,8 @@ func (cpu *Cpu) Execute(code Code) (err error) {
 
 	cp := cpu.Capp
 
-	cpu.Capp.BitsFlipped = 0
+	cpu.Capp.ClearFlipped()
+	cpu.aluFlipped = 0
 
 	next_ip := cpu.Ip + 1
 
@@ -678,11 +761,17 @@ func (cpu *Cpu) Execute(code Code) (err error) {
 	}
 
 	cpu.Ip = next_ip
+	cpu.aluFlipped = bits.OnesCount64(prior ^ result)
+
+	// Leaving IP_MODE_CAPP discards the IRAM.
+	if (cpu.Ip & IP_MODE_MASK) != IP_MODE_CAPP {
+		cpu.InvalidateIram()
+	}
 
 	// only count CAPP ticks against power!
 	if (cpu.Ip & IP_MODE_MASK) != IP_MODE_CAPP {
 		cpu.Ticks += 1
-		cpu.Power += cpu.Capp.BitsFlipped + bits.OnesCount64(prior^result)
+		cpu.Power += cpu.Capp.Flipped() + cpu.aluFlipped
 	}
 
 	return
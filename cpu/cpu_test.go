@@ -215,6 +215,56 @@ func TestCpu_FetchCode_CAPP_Empty(t *testing.T) {
 	assert.ErrorIs(err, ErrIpEmpty)
 }
 
+func TestCpu_FetchCode_Iram(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+
+	cpu.Capp.Action(capp.SET_OF, CAPP_FREE, ^uint32(0))
+	cpu.Capp.Action(capp.LIST_ALL, 0, 0)
+	cpu.Capp.Action(capp.WRITE_FIRST, ARENA_CODE|0x0000_1234, 0xffffffff)
+	cpu.Capp.Action(capp.LIST_NEXT, 0, 0)
+	cpu.Capp.Action(capp.WRITE_FIRST, ARENA_CODE|0x0001_5678, 0xffffffff)
+	cpu.Capp.Action(capp.LIST_NOT, 0, 0)
+
+	cpu.Ip = 0x0001
+	code, err := cpu.FetchCode()
+	assert.NoError(err)
+	assert.Equal(uint16(0x5678), code.Word)
+
+	// Fetch does not use CAPP actions.
+	cpu.Capp.ClearFlipped()
+	cpu.Ip = 0x0000
+	code, err = cpu.FetchCode()
+	assert.NoError(err)
+	assert.Equal(uint16(0x1234), code.Word)
+	assert.Equal(0, cpu.Capp.Flipped())
+
+	// Changing the code arena does not affect the IRAM.
+	cpu.Capp.Action(capp.SET_OF, ARENA_CODE|0x0000_0000, ARENA_MASK|0x3fff_0000)
+	cpu.Capp.Action(capp.LIST_ALL, 0, 0)
+	cpu.Capp.Action(capp.WRITE_FIRST, ARENA_CODE|0x0000_4321, 0xffffffff)
+	code, err = cpu.FetchCode()
+	assert.NoError(err)
+	assert.Equal(uint16(0x1234), code.Word)
+
+	// Until the IRAM is invalidated.
+	cpu.InvalidateIram()
+	code, err = cpu.FetchCode()
+	assert.NoError(err)
+	assert.Equal(uint16(0x4321), code.Word)
+
+	// Leaving IP_MODE_CAPP invalidates the IRAM.
+	cpu.Capp.Action(capp.WRITE_FIRST, ARENA_CODE|0x0000_1111, 0xffffffff)
+	err = cpu.Execute(MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_IP, IR_IMMEDIATE_32, uint16(IP_MODE_REG>>16), 0))
+	assert.NoError(err)
+	cpu.Ip = 0x0000
+	code, err = cpu.FetchCode()
+	assert.NoError(err)
+	assert.Equal(uint16(0x1111), code.Word)
+}
+
 func TestCpu_Tick_WithTrap(t *testing.T) {
 	assert := assert.New(t)
 
@@ -460,13 +510,17 @@ func TestCpu_Execute_COND_InvalidOp(t *testing.T) {
 
 type falseProc struct{}
 
-func (tp *falseProc) Execute(arg uint32, cp *capp.Capp, regs [6]uint32, cond bool) (bool, error) {
+func (tp *falseProc) Defines() iter.Seq2[string, string] { return func(func(string, string) bool) {} }
+
+func (tp *falseProc) Execute(arg uint32, cp capp.Memory, regs [6]uint32, cond bool) (bool, error) {
 	return false, nil
 }
 
 type trueProc struct{}
 
-func (tp *trueProc) Execute(arg uint32, cp *capp.Capp, regs [6]uint32, cond bool) (bool, error) {
+func (tp *trueProc) Defines() iter.Seq2[string, string] { return func(func(string, string) bool) {} }
+
+func (tp *trueProc) Execute(arg uint32, cp capp.Memory, regs [6]uint32, cond bool) (bool, error) {
 	return true, nil
 }
 
@@ -474,13 +528,17 @@ var errAny = errors.New("error")
 
 type errProc struct{}
 
-func (tp *errProc) Execute(arg uint32, cp *capp.Capp, regs [6]uint32, cond bool) (bool, error) {
+func (tp *errProc) Defines() iter.Seq2[string, string] { return func(func(string, string) bool) {} }
+
+func (tp *errProc) Execute(arg uint32, cp capp.Memory, regs [6]uint32, cond bool) (bool, error) {
 	return false, errAny
 }
 
 type condProc struct{}
 
-func (tp *condProc) Execute(arg uint32, cp *capp.Capp, regs [6]uint32, cond bool) (bool, error) {
+func (tp *condProc) Defines() iter.Seq2[string, string] { return func(func(string, string) bool) {} }
+
+func (tp *condProc) Execute(arg uint32, cp capp.Memory, regs [6]uint32, cond bool) (bool, error) {
 	return ((arg & 1) == 1), nil
 }
 
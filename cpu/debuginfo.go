@@ -0,0 +1,61 @@
+package cpu
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// DEBUG_INFO_VERSION is the version of the debug info format.
+const DEBUG_INFO_VERSION = 1
+
+// debugInfo is the JSON encoding of a program's debug info.
+type debugInfo struct {
+	Version   int               `json:"version"`
+	Opcodes   []Opcode          `json:"opcodes"`
+	Data      []uint32          `json:"data,omitempty"`
+	Label     map[string]int    `json:"label,omitempty"`
+	Equate    map[string]string `json:"equate,omitempty"`
+	Expansion map[int][]string  `json:"expansion,omitempty"`
+}
+
+// MarshalDebug writes the debug info of the program: the source location,
+// words and codes of every opcode, and the labels, equates and macro
+// expansions. The debug info is saved next to the program's ring, so that
+// a program booted from the ring can be mapped back to its source.
+func (prog *Program) MarshalDebug(w io.Writer) (err error) {
+	info := debugInfo{
+		Version:   DEBUG_INFO_VERSION,
+		Opcodes:   prog.Opcodes,
+		Data:      prog.Data,
+		Label:     prog.Label,
+		Equate:    prog.Equate,
+		Expansion: prog.Expansion,
+	}
+
+	err = json.NewEncoder(w).Encode(&info)
+	return
+}
+
+// UnmarshalDebug reads a program from its debug info.
+func UnmarshalDebug(r io.Reader) (prog *Program, err error) {
+	var info debugInfo
+	err = json.NewDecoder(r).Decode(&info)
+	if err != nil {
+		return
+	}
+
+	if info.Version != DEBUG_INFO_VERSION {
+		err = ErrDebugVersion
+		return
+	}
+
+	prog = &Program{
+		Opcodes:   info.Opcodes,
+		Data:      info.Data,
+		Label:     info.Label,
+		Equate:    info.Equate,
+		Expansion: info.Expansion,
+	}
+
+	return
+}
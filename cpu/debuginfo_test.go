@@ -0,0 +1,55 @@
+package cpu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram_MarshalDebug(t *testing.T) {
+	assert := assert.New(t)
+
+	program := []string{
+		".macro INNER",
+		"alu add r0 1",
+		".endm",
+		".macro OUTER",
+		"INNER",
+		"alu add r1 2",
+		".endm",
+		"Start:",
+		"write r0 0x10",
+		"OUTER",
+		"jump Start",
+		".dl 0x1234",
+	}
+
+	asm := &Assembler{}
+	asm.Clear()
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+	prog, err := asm.Link()
+	assert.NoError(err)
+
+	assert.Equal(map[int][]string{
+		1: {"INNER stdin:5", "OUTER stdin:10"},
+		2: {"OUTER stdin:10"},
+	}, prog.Expansion)
+
+	var debug bytes.Buffer
+	err = prog.MarshalDebug(&debug)
+	assert.NoError(err)
+
+	loaded, err := UnmarshalDebug(&debug)
+	assert.NoError(err)
+	assert.Equal(prog.Opcodes, loaded.Opcodes)
+	assert.Equal(prog.Data, loaded.Data)
+	assert.Equal(prog.Label, loaded.Label)
+	assert.Equal(prog.Expansion, loaded.Expansion)
+	assert.Equal(prog.Binary(), loaded.Binary())
+
+	_, err = UnmarshalDebug(strings.NewReader(`{"version":99}`))
+	assert.ErrorIs(err, ErrDebugVersion)
+}
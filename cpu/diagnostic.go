@@ -0,0 +1,85 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Diagnostic is an assembly error, located in the source.
+type Diagnostic struct {
+	Filename string   `json:"file"`              // Source file name.
+	LineNo   int      `json:"line"`              // Source line number, from 1.
+	Column   int      `json:"column,omitempty"`  // Column of the offending word, from 1, or 0 if unknown.
+	Message  string   `json:"message"`           // Description of the error.
+	Suggest  []string `json:"suggest,omitempty"` // Similar known words, for an unknown word.
+	Macro    []string `json:"macro,omitempty"`   // Macro expansions of the error, innermost first.
+}
+
+// String returns the diagnostic as 'file:line:column: message'.
+func (d Diagnostic) String() string {
+	text := fmt.Sprintf("%v:%d", d.Filename, d.LineNo)
+	if d.Column != 0 {
+		text += fmt.Sprintf(":%d", d.Column)
+	}
+	text += ": " + d.Message
+	for _, macro := range d.Macro {
+		text += fmt.Sprintf(" (in %v)", macro)
+	}
+	return text
+}
+
+// Diagnostics returns the diagnostics of the errors returned by Parse or
+// Link.
+func Diagnostics(err error) (diagnostics []Diagnostic) {
+	if err == nil {
+		return
+	}
+
+	if list, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range list.Unwrap() {
+			diagnostics = append(diagnostics, Diagnostics(e)...)
+		}
+		return
+	}
+
+	se, ok := err.(*ErrSyntax)
+	if !ok {
+		diagnostics = append(diagnostics, Diagnostic{Message: err.Error()})
+		return
+	}
+
+	d := Diagnostic{
+		Filename: se.Filename,
+		LineNo:   se.LineNo,
+		Column:   se.Column,
+	}
+
+	// Unwrap the macro expansions.
+	inner := se.Err
+	for {
+		if e, ok := inner.(*ErrSyntax); ok {
+			inner = e.Err
+			continue
+		}
+		if e, ok := inner.(*ErrMacro); ok {
+			d.Macro = append(d.Macro, fmt.Sprintf("%v %v:%d", e.Macro, e.Filename, e.LineNo))
+			inner = e.Err
+			continue
+		}
+		break
+	}
+	slices.Reverse(d.Macro)
+
+	d.Message = inner.Error()
+
+	var eu ErrUnknown
+	if errors.As(inner, &eu) {
+		d.Suggest = eu.Suggest
+	}
+
+	diagnostics = append(diagnostics, d)
+	return
+}
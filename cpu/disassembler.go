@@ -0,0 +1,346 @@
+package cpu
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// channelName maps IO channel IDs to their assembler names.
+var channelName = func() (names map[CodeChannel]string) {
+	names = make(map[CodeChannel]string, len(channelMap))
+	for name, channel := range channelMap {
+		names[channel] = name
+	}
+	return
+}()
+
+// disasmLabel returns the generated label name for an IP.
+func disasmLabel(ip int) string {
+	return fmt.Sprintf("L%04x", ip)
+}
+
+// disasmTarget returns the IP target of a linked jump code.
+// The assembler only encodes a 16-bit IP as a 32-bit immediate when
+// linking a label.
+func disasmTarget(code Code) (target int, ok bool) {
+	_, _, arg := code.AluDecode()
+	if arg != IR_IMMEDIATE_32 || len(code.Immediates) != 2 || code.Immediates[0] != 0 {
+		return
+	}
+
+	target = int(code.Immediates[1])
+	ok = true
+	return
+}
+
+// disasmIR returns the assembler word for an immediate-or-register
+// source, and the immediates left over.
+func disasmIR(ir CodeIR, imms []uint16) (word string, rest []uint16, err error) {
+	rest = imms
+	switch ir {
+	case IR_CONST_0:
+		word = "0"
+	case IR_CONST_FFFFFFFF:
+		word = "0xffffffff"
+	case IR_IMMEDIATE_16:
+		if len(rest) < 1 {
+			err = ErrOpcodeImm
+			return
+		}
+		word = fmt.Sprintf("0x%x", rest[0])
+		rest = rest[1:]
+	case IR_IMMEDIATE_32:
+		if len(rest) < 2 {
+			err = ErrOpcodeImm
+			return
+		}
+		word = fmt.Sprintf("0x%x", (uint32(rest[0])<<16)|uint32(rest[1]))
+		rest = rest[2:]
+	default:
+		word = ir.String()
+	}
+
+	return
+}
+
+// disasmMatchMask returns the assembler words for a pair of
+// immediate-or-register sources.
+func disasmMatchMask(match, mask CodeIR, imms []uint16) (words []string, err error) {
+	var word string
+	for _, ir := range []CodeIR{match, mask} {
+		word, imms, err = disasmIR(ir, imms)
+		if err != nil {
+			return
+		}
+		words = append(words, word)
+	}
+
+	return
+}
+
+// disasmCode returns the assembler words for a single code.
+// A 32-bit immediate IP target is returned as a label.
+func disasmCode(code Code) (words []string, label string, err error) {
+	switch code.Cond() {
+	case COND_ALWAYS:
+	case COND_TRUE:
+		words = append(words, "+")
+	case COND_FALSE:
+		words = append(words, "-")
+	default:
+		err = ErrOpcode(code)
+		return
+	}
+
+	var args []string
+	switch code.Class() {
+	case OP_ALU:
+		op, target, arg := code.AluDecode()
+		if op == ALU_OP_SET && target == IR_IP {
+			switch arg {
+			case IR_STACK:
+				words = append(words, "return")
+				return
+			case IR_CONST_FFFFFFFF:
+				words = append(words, "exit")
+				return
+			default:
+				// Not a return or an exit.
+			}
+			if target, ok := disasmTarget(code); ok {
+				label = disasmLabel(target)
+				words = append(words, "jump", label)
+				return
+			}
+		}
+		var word string
+		word, _, err = disasmIR(arg, code.Immediates)
+		if err != nil {
+			return
+		}
+		if op == ALU_OP_SET && target == IR_IP {
+			words = append(words, "vjump", word)
+			return
+		}
+		words = append(words, "alu", op.String(), target.String(), word)
+	case OP_COND:
+		op, a, b := code.CondDecode()
+		switch op {
+		case COND_OP_EQ, COND_OP_NE, COND_OP_LT, COND_OP_LE, COND_OP_GT, COND_OP_GE:
+		default:
+			err = ErrOpcode(code)
+			return
+		}
+		args, err = disasmMatchMask(a, b, code.Immediates)
+		if err != nil {
+			return
+		}
+		words = append(words, "if", op.String()+"?")
+		words = append(words, args...)
+	case OP_CAPP:
+		op, match, mask := code.CappDecode()
+		switch op {
+		case CAPP_OP_LIST_ALL, CAPP_OP_LIST_NOT, CAPP_OP_LIST_NEXT:
+			if match != IR_CONST_0 || mask != IR_CONST_0 {
+				err = ErrOpcode(code)
+				return
+			}
+			words = append(words, "list", op.String())
+			return
+		case CAPP_OP_SET_OF, CAPP_OP_LIST_ONLY:
+			words = append(words, "list", op.String())
+		case CAPP_OP_WRITE_FIRST:
+			words = append(words, "list", "first")
+		case CAPP_OP_WRITE_LIST:
+			words = append(words, "list", "write")
+		default:
+			err = ErrOpcode(code)
+			return
+		}
+		args, err = disasmMatchMask(match, mask, code.Immediates)
+		if err != nil {
+			return
+		}
+		words = append(words, args...)
+	case OP_IO:
+		op, channel, arg := code.IoDecode()
+		name, ok := channelName[channel]
+		if !ok {
+			name = fmt.Sprintf("%d", int(channel))
+		}
+		words = append(words, "io", op.String(), name)
+		if arg != IR_CONST_FFFFFFFF {
+			var word string
+			word, _, err = disasmIR(arg, code.Immediates)
+			if err != nil {
+				return
+			}
+			words = append(words, word)
+		}
+	case OP_COPROC:
+		id, value, mask := code.CoprocDecode()
+		args, err = disasmMatchMask(value, mask, code.Immediates)
+		if err != nil {
+			return
+		}
+		words = append(words, "coproc", id.String())
+		words = append(words, args...)
+	}
+
+	return
+}
+
+// disasmCall returns the assembler words for the final code of a 'call'
+// or 'vcall' sequence.
+func disasmCall(code Code) (words []string, err error) {
+	switch code.Cond() {
+	case COND_ALWAYS, COND_NEVER:
+		// No condition word; disasmCode has rejected COND_NEVER.
+	case COND_TRUE:
+		words = append(words, "+")
+	case COND_FALSE:
+		words = append(words, "-")
+	}
+
+	if target, ok := disasmTarget(code); ok {
+		words = append(words, "call", disasmLabel(target))
+		return
+	}
+
+	_, _, arg := code.AluDecode()
+
+	word, _, err := disasmIR(arg, code.Immediates)
+	if err != nil {
+		return
+	}
+	words = append(words, "vcall", word)
+	return
+}
+
+// DisassembleCode returns the assembler words of a single code. The IP
+// target of a linked jump is returned as its generated label, which is the
+// last of the words.
+func DisassembleCode(code Code) (words []string, label string, err error) {
+	words, label, err = disasmCode(code)
+	return
+}
+
+// Disassemble converts CAPP memory words, in the layout of Program.Binary(),
+// back into a program. The immediates of each code are regrouped with their
+// code by IP, and the targets of jumps and calls are given generated labels.
+// The Words of each opcode are re-assemblable source.
+func Disassemble(bins []uint32) (prog *Program, err error) {
+	prog = &Program{
+		Label: map[string]int{},
+	}
+
+	// Regroup the code words by IP.
+	words := map[int][]uint16{}
+	for _, bin := range bins {
+		switch bin & ARENA_MASK {
+		case ARENA_CODE:
+			ip := int((bin >> 16) & 0x3fff)
+			words[ip] = append(words[ip], uint16(bin&0xffff))
+		case ARENA_DATA:
+			prog.Data = append(prog.Data, bin & ^uint32(ARENA_MASK))
+		default:
+			err = ErrDisassembleArena
+			return
+		}
+	}
+
+	codes := make([]Code, len(words))
+	for ip := range codes {
+		list, ok := words[ip]
+		if !ok {
+			err = ErrDisassembleGap
+			return
+		}
+		code := Code{Word: list[len(list)-1]}
+		if len(list) > 1 {
+			code.Immediates = list[:len(list)-1]
+		}
+		if code.ImmediateNeed() != len(code.Immediates) {
+			err = ErrOpcodeImm
+			return
+		}
+		codes[ip] = code
+	}
+
+	for ip := 0; ip < len(codes); {
+		op := Opcode{LineNo: len(prog.Opcodes) + 1, Ip: ip}
+
+		code := codes[ip]
+		if IsCall(codes[ip:]) {
+			op.Codes = codes[ip : ip+3]
+			op.Words, err = disasmCall(codes[ip+2])
+			if err != nil {
+				return
+			}
+			if _, ok := disasmTarget(codes[ip+2]); ok {
+				op.LinkLabel = op.Words[len(op.Words)-1]
+			}
+		}
+
+		if len(op.Codes) == 0 {
+			op.Codes = codes[ip : ip+1]
+			op.Words, op.LinkLabel, err = disasmCode(code)
+			if err != nil {
+				return
+			}
+		}
+
+		if len(op.LinkLabel) != 0 {
+			target, _ := disasmTarget(op.Codes[len(op.Codes)-1])
+			if target > len(codes) {
+				err = ErrLabelMissing(op.LinkLabel)
+				return
+			}
+			prog.Label[op.LinkLabel] = target
+		}
+
+		prog.Opcodes = append(prog.Opcodes, op)
+		ip += len(op.Codes)
+	}
+
+	return
+}
+
+// WriteSource writes the program as assembly source, with each label
+// on a line of its own before the opcode at its IP.
+func (prog *Program) WriteSource(w io.Writer) (err error) {
+	labels := prog.labelsByIp()
+
+	ip := 0
+	for _, op := range prog.Opcodes {
+		for _, label := range labels[op.Ip] {
+			_, err = fmt.Fprintf(w, "%v:\n", label)
+			if err != nil {
+				return
+			}
+		}
+		_, err = fmt.Fprintln(w, strings.Join(op.Words, " "))
+		if err != nil {
+			return
+		}
+		ip = op.Ip + len(op.Codes)
+	}
+
+	// Labels after the last opcode.
+	for _, label := range labels[ip] {
+		_, err = fmt.Fprintf(w, "%v:\n", label)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, data := range prog.Data {
+		_, err = fmt.Fprintf(w, ".dl 0x%x\n", data)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
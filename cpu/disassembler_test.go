@@ -0,0 +1,154 @@
+package cpu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	assert := assert.New(t)
+
+	program := []string{
+		"jump Main",
+		"Func:",
+		"alu add r0 1",
+		"return",
+		"Main:",
+		"write r0 0x10",
+		"write r1 0x12345678",
+		"call Func",
+		"vcall r1",
+		"+ vjump 0x1",
+		"- exit",
+		"vjump 0x80000000",
+		"if eq? r0 0x11",
+		"if some?",
+		"list of CAPP_FREE",
+		"list all",
+		"list not",
+		"list next",
+		"list only 0x1234 0xff00ff00",
+		"write list ARENA_IO 0xffff0000",
+		"write first 0 0xff",
+		"fetch tape 0xffff",
+		"store vt r2",
+		"alert monitor 0x3",
+		"await monitor r3",
+		"trap",
+		"coproc cp1 r2 0x30",
+		"exit",
+		".dl 0x1234",
+		".db 1 2 3",
+	}
+
+	asm := &Assembler{}
+	asm.Clear()
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+	prog, err := asm.Link()
+	assert.NoError(err)
+
+	disasm, err := Disassemble(prog.Binary())
+	assert.NoError(err)
+
+	var source bytes.Buffer
+	err = disasm.WriteSource(&source)
+	assert.NoError(err)
+
+	expected := []string{
+		"jump L0003",
+		"L0001:",
+		"alu add r0 0x1",
+		"return",
+		"L0003:",
+		"alu set r0 0x10",
+		"alu set r1 0x12345678",
+		"call L0001",
+		"vcall r1",
+		"+ vjump 0x1",
+		"- exit",
+		"vjump 0x80000000",
+		"if eq? r0 0x11",
+		"if gt? count 0",
+		"list of 0xffffffff 0xffffffff",
+		"list all",
+		"list not",
+		"list next",
+		"list only 0x1234 0xff00ff00",
+		"list write 0 0xffff0000",
+		"list first 0 0xff",
+		"io fetch tape 0xffff",
+		"io store vt r2",
+		"io alert monitor 0x3",
+		"io await monitor r3",
+		"io await monitor",
+		"coproc cp1 r2 0x30",
+		"exit",
+		".dl 0x1234",
+		".dl 0x10203",
+	}
+	assert.Equal(strings.Join(expected, "\n")+"\n", source.String())
+
+	// Re-assembling the source yields the same binary.
+	asm.Clear()
+	err = asm.Parse(&source)
+	assert.NoError(err)
+	reprog, err := asm.Link()
+	assert.NoError(err)
+	assert.Equal(prog.Binary(), reprog.Binary())
+}
+
+func TestDisassembleCode(t *testing.T) {
+	assert := assert.New(t)
+
+	table := []struct {
+		Code  Code
+		Words []string
+		Label string
+	}{
+		{Code: MakeCodeAlu(COND_TRUE, ALU_OP_ADD, IR_REG_R0, IR_IMMEDIATE_16, 1), Words: []string{"+", "alu", "add", "r0", "0x1"}},
+		{Code: MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_IP, IR_IMMEDIATE_32, 0, 5), Words: []string{"jump", "L0005"}, Label: "L0005"},
+		{Code: MakeCodeAlu(COND_FALSE, ALU_OP_SET, IR_IP, IR_STACK), Words: []string{"-", "return"}},
+	}
+
+	for _, entry := range table {
+		words, label, err := DisassembleCode(entry.Code)
+		assert.NoError(err)
+		assert.Equal(entry.Words, words)
+		assert.Equal(entry.Label, label)
+	}
+
+	_, _, err := DisassembleCode(MakeCodeExit(COND_NEVER))
+	assert.Error(err)
+}
+
+func TestDisassemble_Errors(t *testing.T) {
+	assert := assert.New(t)
+
+	// IO arena words are not part of a program.
+	_, err := Disassemble([]uint32{ARENA_IO | 0x1234})
+	assert.ErrorIs(err, ErrDisassembleArena)
+
+	// IPs must be contiguous.
+	exit := MakeCodeExit(COND_ALWAYS)
+	_, err = Disassemble([]uint32{ARENA_CODE | (1 << 16) | uint32(exit.Word)})
+	assert.ErrorIs(err, ErrDisassembleGap)
+
+	// Immediates must match the code.
+	set := MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_REG_R0, IR_IMMEDIATE_16)
+	_, err = Disassemble([]uint32{ARENA_CODE | uint32(set.Word)})
+	assert.ErrorIs(err, ErrOpcodeImm)
+
+	// The swap operation has no assembler syntax.
+	swap := MakeCodeCapp(COND_ALWAYS, CAPP_OP_SET_SWAP, IR_CONST_0, IR_CONST_0)
+	_, err = Disassemble([]uint32{ARENA_CODE | uint32(swap.Word)})
+	assert.ErrorIs(err, ErrOpcode(swap))
+
+	// Jump targets must be within the program.
+	jump := MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_IP, IR_IMMEDIATE_32, 0, 5)
+	_, err = Disassemble([]uint32{ARENA_CODE, ARENA_CODE | 5, ARENA_CODE | uint32(jump.Word)})
+	assert.ErrorIs(err, ErrLabelMissing("L0005"))
+}
@@ -2,7 +2,9 @@ package cpu
 
 import (
 	"errors"
+	"strings"
 
+	"github.com/ezrec/ucapp/capp"
 	"github.com/ezrec/ucapp/translate"
 )
 
@@ -41,6 +43,11 @@ var (
 	ErrEquateSyntax       = errors.New(f(".equ syntax"))
 	ErrEquateDuplicate    = errors.New(f(".equ duplicated"))
 	ErrIncludePath        = errors.New(f(".include path must be one word"))
+	ErrIfSyntax           = errors.New(f(".if/.ifdef/.ifndef syntax"))
+	ErrIfLonely           = errors.New(f(".if without .endif"))
+	ErrIfLonelyElse       = errors.New(f(".else without .if"))
+	ErrIfLonelyEndif      = errors.New(f(".endif without .if"))
+	ErrIfElseDuplicate    = errors.New(f(".else duplicated"))
 	ErrLabelDuplicate     = errors.New(f("label duplicated"))
 	ErrMacroSyntax        = errors.New(f(".macro syntax"))
 	ErrMacroNesting       = errors.New(f(".macro in .macro prohibited"))
@@ -55,6 +62,19 @@ var (
 	ErrTargetMissing      = errors.New(f("target missing"))
 	ErrTargetInvalid      = errors.New(f("target invalid"))
 	ErrInstructionInvalid = errors.New(f("instruction invalid"))
+
+	// Disassembler errors
+	ErrDisassembleArena = errors.New(f("word not in code or data arena"))
+	ErrDisassembleGap   = errors.New(f("code has a gap in IPs"))
+
+	// Debug info errors
+	ErrDebugVersion = errors.New(f("debug info version unsupported"))
+
+	// Trace errors
+	ErrTraceVersion = errors.New(f("trace version unsupported"))
+
+	// Save state errors
+	ErrStateCapp = errors.New(f("state capp size mismatch"))
 )
 
 // ErrLabelMissing indicates a missing jump label.
@@ -80,11 +100,15 @@ func (eo ErrOpcode) Is(err error) (ok bool) {
 type ErrSyntax struct {
 	Filename string
 	LineNo   int
+	Column   int // Column of the offending word, from 1, or 0 if unknown.
 	Line     string
 	Err      error
 }
 
 func (err ErrSyntax) Error() string {
+	if err.Column != 0 {
+		return f("at %v:%d:%d \"%v\": %v", err.Filename, err.LineNo, err.Column, err.Line, err.Err)
+	}
 	return f("at %v:%d \"%v\": %v", err.Filename, err.LineNo, err.Line, err.Err)
 }
 
@@ -92,6 +116,43 @@ func (err ErrSyntax) Unwrap() error {
 	return err.Err
 }
 
+// ErrDiagnostics lists all of the errors of an assembly, in source order.
+type ErrDiagnostics []error
+
+func (err ErrDiagnostics) Error() string {
+	texts := make([]string, len(err))
+	for n, e := range err {
+		texts[n] = e.Error()
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (err ErrDiagnostics) Unwrap() []error {
+	return err
+}
+
+// ErrUnknown indicates an unknown word, with the similar known words.
+type ErrUnknown struct {
+	Word    string   // Unknown word.
+	Suggest []string // Similar known words, most similar first.
+	Err     error
+}
+
+func (err ErrUnknown) Error() string {
+	text := err.Err.Error()
+	if !strings.Contains(text, err.Word) {
+		text = f("%v '%v'", text, err.Word)
+	}
+	if len(err.Suggest) != 0 {
+		text += f(" (did you mean '%v'?)", strings.Join(err.Suggest, "', '"))
+	}
+	return text
+}
+
+func (err ErrUnknown) Unwrap() error {
+	return err.Err
+}
+
 // ErrParseCharacter indicates a character ('x') parsing failure.
 type ErrParseCharacter string
 
@@ -129,3 +190,19 @@ func (err ErrMacro) Error() string {
 func (err ErrMacro) Unwrap() error {
 	return err.Err
 }
+
+// ErrWatchpoint indicates the content watchpoints triggered by an instruction.
+type ErrWatchpoint []capp.WatchEvent
+
+func (err ErrWatchpoint) Error() string {
+	events := make([]string, len(err))
+	for n, ev := range err {
+		events[n] = ev.String()
+	}
+	return f("watchpoint %v", strings.Join(events, ", "))
+}
+
+func (err ErrWatchpoint) Is(target error) (ok bool) {
+	_, ok = target.(ErrWatchpoint)
+	return
+}
@@ -0,0 +1,124 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// FORMAT_INDENT is the indentation of the lines of a macro body.
+const FORMAT_INDENT = "    "
+
+// formatLine is a line of formatted source.
+type formatLine struct {
+	indent  string // Indentation of the line.
+	code    string // Code of the line.
+	comment string // Comment of the line, with its leading ';'.
+}
+
+// Format formats assembly source in the canonical style:
+//
+//   - Instructions are spelled in their canonical form: a '+' or '-'
+//     condition, and 'list write', 'list first', 'alu set TARGET' and
+//     'io OP' for the 'write list', 'write first', 'write TARGET' and
+//     'fetch', 'store', 'alert', 'await' and 'trap' alternate syntaxes.
+//   - Words are separated by a single space, and the lines of a macro
+//     body are indented.
+//   - The comments of consecutive lines of code are aligned.
+//   - Runs of blank lines are collapsed into one, and blank lines at the
+//     start and end of the source are removed.
+//
+// A 'write' of a register equate is recognized if the equate is defined by
+// the source itself.
+func Format(src []byte) (out []byte) {
+	equate := map[string]string{}
+	isTarget := func(word string) bool {
+		if value, ok := equate[word]; ok {
+			word = value
+		}
+		_, ok := dstMap[word]
+		return ok
+	}
+
+	var lines []formatLine
+	blank := false
+	macro := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		text, comment, commented := splitComment(scanner.Text())
+		if commented {
+			comment = ";" + strings.TrimRight(comment, " \t")
+		}
+		words := splitWords(strings.TrimSpace(text))
+
+		if len(words) == 0 && !commented {
+			blank = len(lines) != 0
+			continue
+		}
+		if blank {
+			lines = append(lines, formatLine{})
+			blank = false
+		}
+
+		// Labels, including the '.' local labels, precede the
+		// directive or instruction of the line.
+		var labels []string
+		for len(words) != 0 && strings.HasSuffix(words[0], ":") {
+			labels = append(labels, words[0])
+			words = words[1:]
+		}
+
+		indent := ""
+		switch {
+		case len(words) == 0:
+		case words[0] == ".macro":
+			macro = true
+		case words[0] == ".endm":
+			macro = false
+		case words[0] == ".equ" && len(words) == 3:
+			equate[words[1]] = words[2]
+		case strings.HasPrefix(words[0], "."):
+		default:
+			words = canonicalWords(words, isTarget)
+		}
+		words = append(labels, words...)
+		if macro && (len(words) == 0 || words[0] != ".macro") {
+			indent = FORMAT_INDENT
+		}
+
+		lines = append(lines, formatLine{indent: indent, code: strings.Join(words, " "), comment: comment})
+	}
+
+	// Align the comments of each run of lines with code and comments.
+	var buff bytes.Buffer
+	for n := 0; n < len(lines); {
+		end := n + 1
+		width := len(lines[n].indent + lines[n].code)
+		if len(lines[n].code) != 0 && len(lines[n].comment) != 0 {
+			for end < len(lines) && len(lines[end].code) != 0 && len(lines[end].comment) != 0 {
+				width = max(width, len(lines[end].indent+lines[end].code))
+				end++
+			}
+		}
+
+		for _, line := range lines[n:end] {
+			text := line.indent + line.code
+			switch {
+			case len(line.code) == 0 && len(line.comment) == 0:
+				text = ""
+			case len(line.code) == 0:
+				text += line.comment
+			case len(line.comment) != 0:
+				text += strings.Repeat(" ", width-len(text)+1) + line.comment
+			}
+			buff.WriteString(text + "\n")
+		}
+		n = end
+	}
+
+	out = buff.Bytes()
+	return
+}
@@ -0,0 +1,93 @@
+package cpu
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	table := []struct {
+		Source []string
+		Format []string
+	}{
+		{
+			Source: []string{"", "", "  ? exit", "!   fetch tape 0xff", "", "", "", "trap", ""},
+			Format: []string{"+ exit", "- io fetch tape 0xff", "", "io await monitor"},
+		},
+		{
+			Source: []string{"write first 1", "write list 2 3", "write r0 4", "write ip stack", "await vt r1"},
+			Format: []string{"list first 1", "list write 2 3", "alu set r0 4", "alu set ip stack", "io await vt r1"},
+		},
+		{
+			Source: []string{".equ RCOUNT r0", "write RCOUNT 8", "write OTHER 8"},
+			Format: []string{".equ RCOUNT r0", "alu set RCOUNT 8", "write OTHER 8"},
+		},
+		{
+			Source: []string{"list not ; Select 1s", "write list 0 RMASK; Write 1 as 0", "alu shl r0 1", "if eq? first ' '   ; space?   "},
+			Format: []string{"list not           ; Select 1s", "list write 0 RMASK ; Write 1 as 0", "alu shl r0 1", "if eq? first ' ' ; space?"},
+		},
+		{
+			Source: []string{".macro SETX REG", "; Set REG", "write REG 1 ; to one", ".loop:", ".endm", "Main:   SETX r0"},
+			Format: []string{".macro SETX REG", "    ; Set REG", "    write REG 1 ; to one", "    .loop:", ".endm", "Main: SETX r0"},
+		},
+		{
+			Source: []string{".if $(1 +  2)", "  fetch tape", ".endif"},
+			Format: []string{".if $(1 + 2)", "io fetch tape", ".endif"},
+		},
+		{
+			Source: []string{".loop: ? exit", "1:  ! jump 1b", "foo: .bar: trap"},
+			Format: []string{".loop: + exit", "1: - jump 1b", "foo: .bar: io await monitor"},
+		},
+	}
+
+	for n, entry := range table {
+		out := Format([]byte(strings.Join(entry.Source, "\n")))
+		assert.Equal(strings.Join(entry.Format, "\n")+"\n", string(out), "source %d", n)
+		assert.Equal(string(out), string(Format(out)), "source %d", n)
+	}
+}
+
+func TestFormat_Sources(t *testing.T) {
+	assert := assert.New(t)
+
+	var sources []string
+	for _, pattern := range []string{"../examples/*/*.uc", "../os/*.uc", "../os/lib/*.uc"} {
+		matches, err := filepath.Glob(pattern)
+		assert.NoError(err)
+		sources = append(sources, matches...)
+	}
+	assert.NotEmpty(sources)
+
+	// assemble returns the codes, and the number of errors, of a source.
+	assemble := func(src []byte) (codes []Code, errors int) {
+		asm := &Assembler{FS: os.DirFS("..")}
+		asm.Clear()
+		errors = len(Diagnostics(asm.Parse(bytes.NewReader(src))))
+		for _, op := range asm.Opcode {
+			codes = append(codes, op.Codes...)
+		}
+		return
+	}
+
+	for _, source := range sources {
+		src, err := os.ReadFile(source)
+		if !assert.NoError(err) {
+			continue
+		}
+
+		out := Format(src)
+		assert.Equal(string(out), string(Format(out)), source)
+
+		codes, errors := assemble(src)
+		out_codes, out_errors := assemble(out)
+		assert.Equal(codes, out_codes, source)
+		assert.Equal(errors, out_errors, source)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"fmt"
+)
+
+// isLocalLabel returns true for a local label, such as '.L1'.
+func isLocalLabel(label string) bool {
+	return len(label) > 1 && label[0] == '.'
+}
+
+// isNumericLabel returns true for a numeric label, such as '1'.
+func isNumericLabel(label string) bool {
+	if len(label) == 0 {
+		return false
+	}
+
+	for _, c := range label {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// numericReference returns the numeric label, and direction, of a
+// numeric label reference, such as '1f' or '1b'.
+func numericReference(label string) (numeric string, forward bool, ok bool) {
+	if len(label) < 2 {
+		return
+	}
+
+	numeric = label[:len(label)-1]
+	switch label[len(label)-1] {
+	case 'f':
+		forward = true
+	case 'b':
+		forward = false
+	default:
+		return
+	}
+
+	ok = isNumericLabel(numeric)
+	return
+}
+
+// localScope returns the scope of the local labels: the innermost macro
+// expansion, or else the last global label.
+func (asm *Assembler) localScope() string {
+	if len(asm.labelScope) != 0 {
+		return asm.labelScope[len(asm.labelScope)-1]
+	}
+
+	return asm.globalLabel
+}
+
+// scopedLabel returns the name of a label, with the scope of a local label.
+func (asm *Assembler) scopedLabel(label string) string {
+	if isLocalLabel(label) {
+		return asm.localScope() + label
+	}
+
+	return label
+}
+
+// enterMacroScope starts the local label scope of a macro expansion.
+// Returns the function that ends it.
+func (asm *Assembler) enterMacroScope(name string) (end func()) {
+	asm.expansions++
+	asm.labelScope = append(asm.labelScope, fmt.Sprintf("%v@%d", name, asm.expansions))
+
+	end = func() {
+		asm.labelScope = asm.labelScope[:len(asm.labelScope)-1]
+	}
+	return
+}
+
+// numericDefinition is a definition of a numeric label.
+type numericDefinition struct {
+	ip     int      // IP of the definition.
+	source Location // Source location of the definition.
+}
+
+// defineLabel defines a label at the current IP.
+func (asm *Assembler) defineLabel(label string, source Location) (err error) {
+	ip := asm.currentIp()
+
+	if isNumericLabel(label) {
+		if asm.numericLabel == nil {
+			asm.numericLabel = make(map[string][]numericDefinition)
+		}
+		asm.numericLabel[label] = append(asm.numericLabel[label], numericDefinition{ip: ip, source: source})
+		return
+	}
+
+	if !isLocalLabel(label) {
+		asm.globalLabel = label
+	}
+
+	label = asm.scopedLabel(label)
+	_, ok := asm.Label[label]
+	if ok {
+		err = ErrLabelDuplicate
+		return
+	}
+
+	if asm.Label == nil {
+		asm.Label = make(map[string]int, 16)
+	}
+	asm.Label[label] = ip
+
+	if asm.LabelSource == nil {
+		asm.LabelSource = make(map[string]Location, 16)
+	}
+	asm.LabelSource[label] = source
+	return
+}
+
+// resolveLabel returns the IP, and the source location of the definition,
+// of a label referenced by the opcode at an IP. A numeric label reference
+// resolves to the nearest definition of the label after ('1f') or at or
+// before ('1b') the opcode.
+func (asm *Assembler) resolveLabel(label string, at int) (ip int, source Location, err error) {
+	numeric, forward, ok := numericReference(label)
+	if !ok {
+		ip, ok = asm.Label[label]
+		if !ok {
+			err = ErrLabelMissing(label)
+		}
+		source = asm.LabelSource[label]
+		return
+	}
+
+	found := false
+	for _, def := range asm.numericLabel[numeric] {
+		if forward && def.ip > at {
+			ip, source, found = def.ip, def.source, true
+			break
+		}
+		if !forward && def.ip <= at {
+			ip, source, found = def.ip, def.source, true
+		}
+	}
+
+	if !found {
+		err = ErrLabelMissing(label)
+	}
+	return
+}
+
+// LabelDefinition returns the source location of the definition of a
+// label, as referenced by the opcode at an IP. The label of a local label
+// reference is that of the opcode's LinkLabel.
+func (asm *Assembler) LabelDefinition(label string, at int) (source Location, ok bool) {
+	_, source, err := asm.resolveLabel(label, at)
+	ok = err == nil
+	return
+}
+
+// Expansion returns the macro invocations, innermost first, that generated
+// the opcode at an IP.
+func (asm *Assembler) Expansion(ip int) []string {
+	return asm.expansion[ip]
+}
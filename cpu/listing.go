@@ -0,0 +1,106 @@
+package cpu
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// labelsByIp returns the program's labels, grouped by IP.
+func (prog *Program) labelsByIp() (labels map[int][]string) {
+	labels = map[int][]string{}
+	for _, label := range slices.Sorted(maps.Keys(prog.Label)) {
+		ip := prog.Label[label]
+		labels[ip] = append(labels[ip], label)
+	}
+	return
+}
+
+// WriteListing writes an assembler listing of the program. Each code
+// is listed with its source location, IP, encoded word and immediates,
+// followed by the source words on the first code of each opcode:
+//
+//	FILE:LINE  IP    WORD   IMMEDIATES  SOURCE
+//
+// Labels are listed before the first code at their IP, and the data
+// section is listed after the code.
+func (prog *Program) WriteListing(w io.Writer) (err error) {
+	labels := prog.labelsByIp()
+
+	// Width of the source location column.
+	width := 16
+	for _, op := range prog.Opcodes {
+		width = max(width, len(fmt.Sprintf("%v:%d", op.Filename, op.LineNo)))
+	}
+	indent := strings.Repeat(" ", width+1+4+1+4+1+9+1)
+
+	ip := 0
+	for _, op := range prog.Opcodes {
+		for _, label := range labels[op.Ip] {
+			_, err = fmt.Fprintf(w, "%v%v:\n", indent, label)
+			if err != nil {
+				return
+			}
+		}
+		location := fmt.Sprintf("%v:%d", op.Filename, op.LineNo)
+		for n, code := range op.Codes {
+			var imms []string
+			for _, imm := range code.Immediates {
+				imms = append(imms, fmt.Sprintf("%04x", imm))
+			}
+			source := ""
+			if n == 0 {
+				source = strings.Join(op.Words, " ")
+			}
+			line := fmt.Sprintf("%-*v %04x %04x %-9v %v",
+				width, location, op.Ip+n, code.Word, strings.Join(imms, " "), source)
+			_, err = fmt.Fprintln(w, strings.TrimRight(line, " "))
+			if err != nil {
+				return
+			}
+			location = ""
+		}
+		ip = op.Ip + len(op.Codes)
+	}
+
+	for _, label := range labels[ip] {
+		_, err = fmt.Fprintf(w, "%v%v:\n", indent, label)
+		if err != nil {
+			return
+		}
+	}
+
+	for n, data := range prog.Data {
+		_, err = fmt.Fprintf(w, "%-*v %04x %08x\n", width, "data", n, data)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// WriteSymbols writes the symbol map of the program: the IP of each
+// label, in IP order, followed by the value of each equate, in name order.
+func (prog *Program) WriteSymbols(w io.Writer) (err error) {
+	labels := prog.labelsByIp()
+	for _, ip := range slices.Sorted(maps.Keys(labels)) {
+		for _, label := range labels[ip] {
+			_, err = fmt.Fprintf(w, "label 0x%04x %v\n", ip, label)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(prog.Equate)) {
+		_, err = fmt.Fprintf(w, "equ %v %v\n", name, prog.Equate[name])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
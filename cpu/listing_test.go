@@ -0,0 +1,89 @@
+package cpu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func doListingProgram(t *testing.T) (prog *Program) {
+	assert := assert.New(t)
+
+	program := []string{
+		".equ COUNT 3",
+		".equ TODO $(1 << 8)",
+		"jump Main",
+		"Func:",
+		"alu add r0 3",
+		"return",
+		"Main: Start:",
+		"write r1 0x12345678",
+		"call Func",
+		"End:",
+		".dl TODO",
+	}
+
+	asm := &Assembler{}
+	asm.Predefine("CAPP_SIZE", "8192")
+	asm.Clear()
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+	prog, err = asm.Link()
+	assert.NoError(err)
+
+	for n := range prog.Opcodes {
+		prog.Opcodes[n].Filename = "test.uc"
+	}
+
+	return
+}
+
+func TestProgram_WriteListing(t *testing.T) {
+	assert := assert.New(t)
+
+	prog := doListingProgram(t)
+
+	var listing bytes.Buffer
+	err := prog.WriteListing(&listing)
+	assert.NoError(err)
+
+	expected := []string{
+		"test.uc:3        0000 006f 0000 0003 jump Main",
+		"                                     Func:",
+		"test.uc:5        0001 060e 0003      alu add r0 3",
+		"test.uc:6        0002 0067           return",
+		"                                     Main:",
+		"                                     Start:",
+		"test.uc:8        0003 001f 1234 5678 write r1 0x12345678",
+		"test.uc:9        0004 007e 0001      call Func",
+		"                 0005 0676",
+		"                 0006 006f 0000 0001",
+		"                                     End:",
+		"data             0000 00000100",
+	}
+
+	assert.Equal(strings.Join(expected, "\n")+"\n", listing.String())
+}
+
+func TestProgram_WriteSymbols(t *testing.T) {
+	assert := assert.New(t)
+
+	prog := doListingProgram(t)
+
+	var symbols bytes.Buffer
+	err := prog.WriteSymbols(&symbols)
+	assert.NoError(err)
+
+	expected := []string{
+		"label 0x0001 Func",
+		"label 0x0003 Main",
+		"label 0x0003 Start",
+		"label 0x0007 End",
+		"equ COUNT 3",
+		"equ TODO 0x100",
+	}
+
+	assert.Equal(strings.Join(expected, "\n")+"\n", symbols.String())
+}
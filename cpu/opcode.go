@@ -2,6 +2,7 @@ package cpu
 
 import (
 	"fmt"
+	"slices"
 )
 
 // CodeCond is a condition code.
@@ -284,6 +285,31 @@ func (code Code) ImmediateNeed() int {
 	return need
 }
 
+// equal returns true if two codes have the same word and immediates.
+func (code Code) equal(other Code) bool {
+	return code.Word == other.Word && slices.Equal(code.Immediates, other.Immediates)
+}
+
+// IsCall returns true if the codes start with the sequence of a 'call' or
+// 'vcall': the push of the return IP to the stack, and the set of the IP,
+// under the same condition.
+func IsCall(codes []Code) bool {
+	if len(codes) < 3 {
+		return false
+	}
+
+	cond := codes[0].Cond()
+	if cond == COND_NEVER ||
+		!codes[0].equal(MakeCodeAlu(cond, ALU_OP_SET, IR_STACK, IR_IMMEDIATE_16, 1)) ||
+		!codes[1].equal(MakeCodeAlu(cond, ALU_OP_ADD, IR_STACK, IR_IP)) ||
+		codes[2].Cond() != cond || codes[2].Class() != OP_ALU {
+		return false
+	}
+
+	op, target, _ := codes[2].AluDecode()
+	return op == ALU_OP_SET && target == IR_IP
+}
+
 // String returns the assembly language representation of this instruction.
 func (code Code) String() (out string) {
 	cond := code.Cond()
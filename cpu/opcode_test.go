@@ -0,0 +1,26 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCall(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+	err := asm.Parse(strings.NewReader("Main:\ncall Main\n+ call Main\n- vcall r0\njump Main\nwrite r0 1\n"))
+	assert.NoError(err)
+	_, err = asm.Link()
+	assert.NoError(err)
+
+	var calls []bool
+	for _, op := range asm.Opcode {
+		calls = append(calls, IsCall(op.Codes))
+	}
+	assert.Equal([]bool{true, true, true, false, false}, calls)
+	assert.False(IsCall(asm.Opcode[0].Codes[1:]))
+}
@@ -0,0 +1,342 @@
+package cpu
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProfileCost is the cost of executing instructions.
+type ProfileCost struct {
+	Ticks       int // Instructions executed.
+	CappFlipped int // CAPP bits flipped.
+	AluFlipped  int // ALU bits flipped.
+}
+
+// Power returns the power (bits flipped) of the cost.
+func (pc ProfileCost) Power() int {
+	return pc.CappFlipped + pc.AluFlipped
+}
+
+// add accumulates another cost.
+func (pc *ProfileCost) add(other ProfileCost) {
+	pc.Ticks += other.Ticks
+	pc.CappFlipped += other.CappFlipped
+	pc.AluFlipped += other.AluFlipped
+}
+
+// ProfileEntry is the cost attributed to an IP, source line or label.
+type ProfileEntry struct {
+	Name string // IP, source line or label.
+	ProfileCost
+}
+
+// Profile is a Tracer that attributes the cost of every executed
+// instruction to its IP. Every instruction is one tick.
+type Profile struct {
+	Cost map[uint32]*ProfileCost // Map of IPs to their cost.
+}
+
+var _ Tracer = (*Profile)(nil)
+
+// NewProfile creates an empty profile.
+func NewProfile() (prof *Profile) {
+	prof = &Profile{
+		Cost: map[uint32]*ProfileCost{},
+	}
+	return
+}
+
+// Trace attributes the cost of an executed instruction to its IP.
+func (prof *Profile) Trace(event *TraceEvent) {
+	cost, ok := prof.Cost[event.Ip]
+	if !ok {
+		cost = &ProfileCost{}
+		prof.Cost[event.Ip] = cost
+	}
+
+	cost.add(ProfileCost{
+		Ticks:       1,
+		CappFlipped: event.BitsFlipped,
+		AluFlipped:  event.AluFlipped,
+	})
+}
+
+// Total returns the total cost of the profile.
+func (prof *Profile) Total() (total ProfileCost) {
+	for _, cost := range prof.Cost {
+		total.add(*cost)
+	}
+	return
+}
+
+// profileIp is the name of an IP in a profile.
+func profileIp(ip uint32) string {
+	return fmt.Sprintf("0x%08x", ip)
+}
+
+// profileDebug returns the debug info of an IP, if the IP is in the program.
+func profileDebug(prog *Program, ip uint32) (dbg Debug) {
+	if prog != nil && (ip&IP_MODE_MASK) == IP_MODE_CAPP {
+		dbg = prog.Debug(uint16(ip))
+	}
+	return
+}
+
+// profileLine returns the source line of an IP, or the IP if the IP is
+// not in the program.
+func profileLine(prog *Program, ip uint32) (line string) {
+	dbg := profileDebug(prog, ip)
+	if dbg.Opcode == nil {
+		line = profileIp(ip)
+		return
+	}
+
+	line = fmt.Sprintf("%v:%d", dbg.Filename, dbg.LineNo)
+	return
+}
+
+// profileLabel returns the label that an IP is in: the closest label at or
+// before the IP. Returns the IP if there is no such label.
+func profileLabel(prog *Program, ip uint32) (label string) {
+	label = profileIp(ip)
+	if prog == nil || (ip&IP_MODE_MASK) != IP_MODE_CAPP {
+		return
+	}
+
+	best := -1
+	for name, label_ip := range prog.Label {
+		if label_ip > int(ip) || label_ip < best {
+			continue
+		}
+		if label_ip == best && name > label {
+			continue
+		}
+		best = label_ip
+		label = name
+	}
+
+	return
+}
+
+// by gathers the profile costs by name, sorted by power, then ticks,
+// then name.
+func (prof *Profile) by(name func(ip uint32) string) (entries []ProfileEntry) {
+	costs := map[string]*ProfileCost{}
+	for ip, cost := range prof.Cost {
+		key := name(ip)
+		total, ok := costs[key]
+		if !ok {
+			total = &ProfileCost{}
+			costs[key] = total
+		}
+		total.add(*cost)
+	}
+
+	for key, cost := range costs {
+		entries = append(entries, ProfileEntry{Name: key, ProfileCost: *cost})
+	}
+
+	slices.SortFunc(entries, func(a, b ProfileEntry) int {
+		return cmp.Or(
+			cmp.Compare(b.Power(), a.Power()),
+			cmp.Compare(b.Ticks, a.Ticks),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	return
+}
+
+// ByIp returns the cost of each IP, most power first.
+func (prof *Profile) ByIp() []ProfileEntry {
+	return prof.by(profileIp)
+}
+
+// ByLine returns the cost of each source line of a program, most power first.
+func (prof *Profile) ByLine(prog *Program) []ProfileEntry {
+	return prof.by(func(ip uint32) string { return profileLine(prog, ip) })
+}
+
+// ByLabel returns the cost of each label of a program, most power first.
+// The cost of an IP is attributed to the closest label at or before it.
+func (prof *Profile) ByLabel(prog *Program) []ProfileEntry {
+	return prof.by(func(ip uint32) string { return profileLabel(prog, ip) })
+}
+
+// WriteReport writes a text report of the profile: the total cost, followed
+// by the cost of each label, source line, and IP, most power first.
+func (prof *Profile) WriteReport(w io.Writer, prog *Program) (err error) {
+	total := prof.Total()
+
+	_, err = fmt.Fprintf(w, "total: %d ticks, %d power (%d capp, %d alu)\n",
+		total.Ticks, total.Power(), total.CappFlipped, total.AluFlipped)
+	if err != nil {
+		return
+	}
+
+	sections := []struct {
+		title   string
+		entries []ProfileEntry
+	}{
+		{"label", prof.ByLabel(prog)},
+		{"line", prof.ByLine(prog)},
+		{"ip", prof.ByIp()},
+	}
+
+	for _, section := range sections {
+		width := len(section.title)
+		for _, entry := range section.entries {
+			width = max(width, len(entry.Name))
+		}
+
+		_, err = fmt.Fprintf(w, "\n%-*s %10s %10s %10s %10s %6s\n", width, section.title,
+			"ticks", "power", "capp", "alu", "power%")
+		if err != nil {
+			return
+		}
+		for _, entry := range section.entries {
+			percent := 0.0
+			if total.Power() != 0 {
+				percent = 100.0 * float64(entry.Power()) / float64(total.Power())
+			}
+			_, err = fmt.Fprintf(w, "%-*s %10d %10d %10d %10d %6.2f\n", width, entry.Name,
+				entry.Ticks, entry.Power(), entry.CappFlipped, entry.AluFlipped, percent)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// pprof profile.proto field numbers.
+const (
+	pprofProfileSampleType  = 1
+	pprofProfileSample      = 2
+	pprofProfileLocation    = 4
+	pprofProfileFunction    = 5
+	pprofProfileStringTable = 6
+
+	pprofProfileDefaultSampleType = 14
+
+	pprofValueTypeType = 1
+	pprofValueTypeUnit = 2
+
+	pprofSampleLocationId = 1
+	pprofSampleValue      = 2
+
+	pprofLocationId      = 1
+	pprofLocationAddress = 3
+	pprofLocationLine    = 4
+
+	pprofLineFunctionId = 1
+	pprofLineLine       = 2
+
+	pprofFunctionId         = 1
+	pprofFunctionName       = 2
+	pprofFunctionSystemName = 3
+	pprofFunctionFilename   = 4
+)
+
+// pprofMessage appends an embedded message field.
+func pprofMessage(b []byte, field protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// pprofVarint appends a varint field.
+func pprofVarint(b []byte, field protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, field, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+// WritePprof writes the profile in the (uncompressed) pprof protocol
+// buffer format, with one location per IP. The function of a location is
+// the label the IP is in, and its line is the IP's source line.
+func (prof *Profile) WritePprof(w io.Writer, prog *Program) (err error) {
+	strs := []string{""}
+	str_index := map[string]uint64{"": 0}
+	str := func(s string) uint64 {
+		index, ok := str_index[s]
+		if !ok {
+			index = uint64(len(strs))
+			strs = append(strs, s)
+			str_index[s] = index
+		}
+		return index
+	}
+
+	var b []byte
+
+	for _, sample_type := range [][2]string{
+		{"ticks", "count"},
+		{"power", "bits"},
+		{"capp", "bits"},
+		{"alu", "bits"},
+	} {
+		var vt []byte
+		vt = pprofVarint(vt, pprofValueTypeType, str(sample_type[0]))
+		vt = pprofVarint(vt, pprofValueTypeUnit, str(sample_type[1]))
+		b = pprofMessage(b, pprofProfileSampleType, vt)
+	}
+
+	functions := map[string]uint64{}
+	for n, ip := range slices.Sorted(maps.Keys(prof.Cost)) {
+		cost := prof.Cost[ip]
+		location_id := uint64(n + 1)
+
+		var sample []byte
+		sample = pprofVarint(sample, pprofSampleLocationId, location_id)
+		for _, value := range []int{cost.Ticks, cost.Power(), cost.CappFlipped, cost.AluFlipped} {
+			sample = pprofVarint(sample, pprofSampleValue, uint64(value))
+		}
+		b = pprofMessage(b, pprofProfileSample, sample)
+
+		dbg := profileDebug(prog, ip)
+		filename := ""
+		lineno := 0
+		if dbg.Opcode != nil {
+			filename = dbg.Filename
+			lineno = dbg.LineNo
+		}
+
+		label := profileLabel(prog, ip)
+		function_id, ok := functions[label]
+		if !ok {
+			function_id = uint64(len(functions) + 1)
+			functions[label] = function_id
+			var function []byte
+			function = pprofVarint(function, pprofFunctionId, function_id)
+			function = pprofVarint(function, pprofFunctionName, str(label))
+			function = pprofVarint(function, pprofFunctionSystemName, str(label))
+			function = pprofVarint(function, pprofFunctionFilename, str(filename))
+			b = pprofMessage(b, pprofProfileFunction, function)
+		}
+
+		var line []byte
+		line = pprofVarint(line, pprofLineFunctionId, function_id)
+		line = pprofVarint(line, pprofLineLine, uint64(lineno))
+
+		var location []byte
+		location = pprofVarint(location, pprofLocationId, location_id)
+		location = pprofVarint(location, pprofLocationAddress, uint64(ip))
+		location = pprofMessage(location, pprofLocationLine, line)
+		b = pprofMessage(b, pprofProfileLocation, location)
+	}
+
+	b = pprofVarint(b, pprofProfileDefaultSampleType, str("power"))
+
+	for _, s := range strs {
+		b = protowire.AppendTag(b, pprofProfileStringTable, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+
+	_, err = w.Write(b)
+	return
+}
@@ -0,0 +1,122 @@
+package cpu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var profileProgram = &Program{
+	Opcodes: []Opcode{
+		{Filename: "main.uc", LineNo: 1, Ip: 0, Codes: make([]Code, 1)},
+		{Filename: "main.uc", LineNo: 2, Ip: 1, Codes: make([]Code, 2)},
+		{Filename: "main.uc", LineNo: 4, Ip: 3, Codes: make([]Code, 1)},
+	},
+	Label: map[string]int{
+		"Start": 0,
+		"Loop":  1,
+	},
+}
+
+func profileEvents(prof *Profile) {
+	events := []TraceEvent{
+		{Ip: 0, AluFlipped: 1},
+		{Ip: 1, BitsFlipped: 10, AluFlipped: 2},
+		{Ip: 2, BitsFlipped: 20},
+		{Ip: 1, BitsFlipped: 10, AluFlipped: 2},
+		{Ip: 2, BitsFlipped: 20},
+		{Ip: 3, AluFlipped: 5},
+		{Ip: IP_MODE_REG | 2, AluFlipped: 3},
+	}
+	for _, event := range events {
+		prof.Trace(&event)
+	}
+}
+
+func TestProfile_By(t *testing.T) {
+	assert := assert.New(t)
+
+	prof := NewProfile()
+	profileEvents(prof)
+
+	assert.Equal(ProfileCost{Ticks: 7, CappFlipped: 60, AluFlipped: 13}, prof.Total())
+
+	assert.Equal([]ProfileEntry{
+		{"Loop", ProfileCost{Ticks: 5, CappFlipped: 60, AluFlipped: 9}},
+		{"0x80000002", ProfileCost{Ticks: 1, AluFlipped: 3}},
+		{"Start", ProfileCost{Ticks: 1, AluFlipped: 1}},
+	}, prof.ByLabel(profileProgram))
+
+	assert.Equal([]ProfileEntry{
+		{"main.uc:2", ProfileCost{Ticks: 4, CappFlipped: 60, AluFlipped: 4}},
+		{"main.uc:4", ProfileCost{Ticks: 1, AluFlipped: 5}},
+		{"0x80000002", ProfileCost{Ticks: 1, AluFlipped: 3}},
+		{"main.uc:1", ProfileCost{Ticks: 1, AluFlipped: 1}},
+	}, prof.ByLine(profileProgram))
+
+	entries := prof.ByIp()
+	assert.Len(entries, 5)
+	assert.Equal("0x00000002", entries[0].Name)
+
+	// Without a program, everything is by IP.
+	assert.Equal(entries, prof.ByLabel(nil))
+}
+
+func TestProfile_WriteReport(t *testing.T) {
+	assert := assert.New(t)
+
+	prof := NewProfile()
+	profileEvents(prof)
+
+	var buff bytes.Buffer
+	assert.NoError(prof.WriteReport(&buff, profileProgram))
+
+	report := buff.String()
+	assert.True(strings.HasPrefix(report, "total: 7 ticks, 73 power (60 capp, 13 alu)\n"))
+	assert.Contains(report, "\nlabel           ticks      power       capp        alu power%\n"+
+		"Loop                5         69         60          9  94.52\n")
+	assert.Contains(report, "\nmain.uc:2           4         64         60          4  87.67\n")
+}
+
+func TestProfile_WritePprof(t *testing.T) {
+	assert := assert.New(t)
+
+	prof := NewProfile()
+	profileEvents(prof)
+
+	var buff bytes.Buffer
+	assert.NoError(prof.WritePprof(&buff, profileProgram))
+
+	fields := map[protowire.Number]int{}
+	var strs []string
+	b := buff.Bytes()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if !assert.GreaterOrEqual(n, 0) {
+			return
+		}
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if !assert.GreaterOrEqual(n, 0) {
+			return
+		}
+		if num == pprofProfileStringTable {
+			s, _ := protowire.ConsumeString(b)
+			strs = append(strs, s)
+		}
+		fields[num]++
+		b = b[n:]
+	}
+
+	assert.Equal(4, fields[pprofProfileSampleType])
+	assert.Equal(5, fields[pprofProfileSample])
+	assert.Equal(5, fields[pprofProfileLocation])
+	assert.Equal(3, fields[pprofProfileFunction])
+	assert.Equal(1, fields[pprofProfileDefaultSampleType])
+	assert.Equal("", strs[0])
+	assert.Contains(strs, "Loop")
+	assert.Contains(strs, "main.uc")
+}
@@ -6,8 +6,11 @@ import (
 
 // Program is a list of opcodes.
 type Program struct {
-	Opcodes []Opcode // Opcodes and metadata
-	Data    []uint32 // Data section
+	Opcodes   []Opcode          // Opcodes and metadata
+	Data      []uint32          // Data section
+	Label     map[string]int    // Map of jump labels to IPs.
+	Equate    map[string]string // Map of equates defined by the program source.
+	Expansion map[int][]string  // Map of opcode IPs to the macro invocations that generated them, innermost first.
 }
 
 // Debug contains debugging information for a program instruction pointer.
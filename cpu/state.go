@@ -0,0 +1,182 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+// CpuState is the saved state of the CPU and its CAPP.
+type CpuState struct {
+	Ip        uint32          `json:"ip"`             // Current instruction pointer.
+	Cond      bool            `json:"cond"`           // Current conditional execution state.
+	Register  [6]uint32       `json:"register"`       // Register bank.
+	Match     uint32          `json:"match"`          // Match value sent to the CAPP.
+	Mask      uint32          `json:"mask"`           // Mask value sent to the CAPP.
+	Stack     []uint32        `json:"stack"`          // Stack contents, bottom first.
+	Power     int             `json:"power"`          // Power counter.
+	Ticks     int             `json:"ticks"`          // CPU ticks counter.
+	IramValid bool            `json:"iram_valid"`     // Set when the IRAM holds the code arena.
+	Iram      map[uint16]Code `json:"iram,omitempty"` // Decoded code arena, by IP.
+	Response  [8][]uint32     `json:"response"`       // Pending responses of each channel.
+	Capp      *capp.State     `json:"capp"`           // CAPP content.
+}
+
+// SaveState returns the state of the CPU. The channel models are not
+// included; only the responses the CPU has not yet read from them.
+func (cpu *Cpu) SaveState() (state *CpuState) {
+	state = &CpuState{
+		Ip:        cpu.Ip,
+		Cond:      cpu.Cond,
+		Register:  cpu.Register,
+		Match:     cpu.Match,
+		Mask:      cpu.Mask,
+		Stack:     slices.Clone(cpu.Stack.Data),
+		Power:     cpu.Power,
+		Ticks:     cpu.Ticks,
+		IramValid: cpu.iramValid,
+		Iram:      maps.Clone(cpu.iram),
+		Capp:      capp.SaveState(cpu.Capp),
+	}
+
+	for n, ch := range cpu.channel {
+		if ch == nil {
+			continue
+		}
+		state.Response[n] = drain(ch.Response)
+		for _, value := range state.Response[n] {
+			ch.Response <- value
+		}
+	}
+
+	return
+}
+
+// LoadState restores the state of the CPU. The CAPP must be of the same
+// size as the saved CAPP.
+func (cpu *Cpu) LoadState(state *CpuState) (err error) {
+	if state.Capp == nil || uint(len(state.Capp.Data)) != cpu.Capp.Size() {
+		err = ErrStateCapp
+		return
+	}
+
+	if len(state.Stack) > STACK_LIMIT {
+		err = ErrStackFull
+		return
+	}
+
+	for n, values := range state.Response {
+		if len(values) == 0 {
+			continue
+		}
+		ch := cpu.channel[n]
+		if ch == nil {
+			err = fmt.Errorf("%w: %v", ErrChannelInvalid, CodeChannel(n))
+			return
+		}
+		if len(values) > cap(ch.Response) {
+			err = fmt.Errorf("%w: %v", ErrChannelFull, CodeChannel(n))
+			return
+		}
+	}
+
+	err = capp.LoadState(cpu.Capp, state.Capp)
+	if err != nil {
+		return
+	}
+
+	cpu.Ip = state.Ip
+	cpu.Cond = state.Cond
+	cpu.Register = state.Register
+	cpu.Match = state.Match
+	cpu.Mask = state.Mask
+	cpu.Stack.Data = slices.Clone(state.Stack)
+	cpu.Power = state.Power
+	cpu.Ticks = state.Ticks
+	cpu.iram = maps.Clone(state.Iram)
+	cpu.iramValid = state.IramValid
+	cpu.aluFlipped = 0
+
+	for n, ch := range cpu.channel {
+		if ch == nil {
+			continue
+		}
+		drain(ch.Response)
+		for _, value := range state.Response[n] {
+			ch.Response <- value
+		}
+	}
+
+	return
+}
+
+// drain reads all of the pending values of a response channel.
+func drain(response chan uint32) (values []uint32) {
+	for {
+		select {
+		case value := <-response:
+			values = append(values, value)
+		default:
+			return
+		}
+	}
+}
+
+// CpuSnapshot is a copy of the registers of the CPU, without its CAPP or
+// channels. It is cheap enough to take before every instruction.
+type CpuSnapshot struct {
+	Ip       uint32    // Current instruction pointer.
+	Cond     bool      // Current conditional execution state.
+	Register [6]uint32 // Register bank.
+	Match    uint32    // Match value sent to the CAPP.
+	Mask     uint32    // Mask value sent to the CAPP.
+	Stack    []uint32  // Stack contents, bottom first.
+	Power    int       // Power counter.
+	Ticks    int       // CPU ticks counter.
+
+	aluFlipped int             // ALU bits flipped by the last instruction.
+	iram       map[uint16]Code // Decoded code arena. A loaded IRAM is never modified.
+	iramValid  bool            // Set when the IRAM holds the code arena.
+}
+
+// Snapshot returns a copy of the registers of the CPU.
+func (cpu *Cpu) Snapshot() (snap CpuSnapshot) {
+	snap = CpuSnapshot{
+		Ip:         cpu.Ip,
+		Cond:       cpu.Cond,
+		Register:   cpu.Register,
+		Match:      cpu.Match,
+		Mask:       cpu.Mask,
+		Stack:      slices.Clone(cpu.Stack.Data),
+		Power:      cpu.Power,
+		Ticks:      cpu.Ticks,
+		aluFlipped: cpu.aluFlipped,
+		iram:       cpu.iram,
+		iramValid:  cpu.iramValid,
+	}
+	return
+}
+
+// Restore restores the registers of the CPU from a snapshot.
+func (cpu *Cpu) Restore(snap CpuSnapshot) {
+	cpu.Ip = snap.Ip
+	cpu.Cond = snap.Cond
+	cpu.Register = snap.Register
+	cpu.Match = snap.Match
+	cpu.Mask = snap.Mask
+	cpu.Stack.Data = slices.Clone(snap.Stack)
+	cpu.Power = snap.Power
+	cpu.Ticks = snap.Ticks
+	cpu.aluFlipped = snap.aluFlipped
+	cpu.iram = snap.iram
+	cpu.iramValid = snap.iramValid
+
+	// The CAPP may have been restored beneath the watcher.
+	if cpu.watcher != nil {
+		cpu.watcher.Sync()
+	}
+}
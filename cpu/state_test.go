@@ -0,0 +1,111 @@
+package cpu
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+func TestCpu_State(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+	cpu.SetChannel(CHANNEL_ID_TAPE, &dummyIo{})
+	cpu.Ip = IP_MODE_CAPP | 0x12
+	cpu.Cond = true
+	cpu.Register[2] = 0x1234
+	cpu.Match = 0x55
+	cpu.Mask = 0xff
+	cpu.Stack.Push(0x11)
+	cpu.Stack.Push(0x22)
+	cpu.Power = 100
+	cpu.Ticks = 10
+	cpu.Capp.Action(capp.WRITE_FIRST, 0x4321, 0xffffffff)
+	cpu.Capp.Action(capp.LIST_NEXT, 0, 0)
+
+	_, response, err := cpu.GetChannel(CHANNEL_ID_TAPE)
+	assert.NoError(err)
+	response <- 7
+	response <- 8
+
+	data, err := json.Marshal(cpu.SaveState())
+	assert.NoError(err)
+
+	// Saving the state keeps the pending responses.
+	assert.Equal(2, len(response))
+
+	var state CpuState
+	assert.NoError(json.Unmarshal(data, &state))
+
+	restored := NewCpu(64)
+	defer restored.Close()
+	restored.SetChannel(CHANNEL_ID_TAPE, &dummyIo{})
+	assert.NoError(restored.LoadState(&state))
+
+	assert.Equal(cpu.Ip, restored.Ip)
+	assert.Equal(cpu.Cond, restored.Cond)
+	assert.Equal(cpu.Register, restored.Register)
+	assert.Equal(cpu.Match, restored.Match)
+	assert.Equal(cpu.Mask, restored.Mask)
+	assert.Equal(cpu.Stack.Data, restored.Stack.Data)
+	assert.Equal(cpu.Power, restored.Power)
+	assert.Equal(cpu.Ticks, restored.Ticks)
+	assert.Equal(cpu.Capp.Export(), restored.Capp.Export())
+	assert.Equal(slices.Collect(cpu.Capp.List), slices.Collect(restored.Capp.List))
+
+	_, response, err = restored.GetChannel(CHANNEL_ID_TAPE)
+	assert.NoError(err)
+	assert.Equal(uint32(7), <-response)
+	assert.Equal(uint32(8), <-response)
+}
+
+func TestCpu_State_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+	state := cpu.SaveState()
+
+	assert.ErrorIs(NewCpu(32).LoadState(state), ErrStateCapp)
+
+	state.Response[CHANNEL_ID_TAPE] = []uint32{1}
+	assert.ErrorIs(cpu.LoadState(state), ErrChannelInvalid)
+	state.Response[CHANNEL_ID_TAPE] = nil
+
+	state.Stack = make([]uint32, STACK_LIMIT+1)
+	assert.ErrorIs(cpu.LoadState(state), ErrStackFull)
+}
+
+func TestCpu_Snapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+	cpu.Ip = 0x100
+	cpu.Stack.Push(0x11)
+
+	snap := cpu.Snapshot()
+	before := cpu.TraceState()
+
+	codes := []Code{
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_REG_R2, IR_IMMEDIATE_16, 0x1234),
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_ADD, IR_STACK, IR_IMMEDIATE_16, 0x2),
+		MakeCodeCond(COND_ALWAYS, COND_OP_EQ, IR_REG_R2, IR_IMMEDIATE_16, 0x1234),
+	}
+	for _, code := range codes {
+		assert.NoError(cpu.Execute(code))
+	}
+	assert.NotEqual(before, cpu.TraceState())
+
+	cpu.Restore(snap)
+	assert.Equal(before, cpu.TraceState())
+
+	// The snapshot is not changed by later instructions.
+	cpu.Stack.Push(0x22)
+	assert.Equal([]uint32{0x11}, snap.Stack)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// SUGGEST_LIMIT is the maximum number of suggestions for an unknown word.
+const SUGGEST_LIMIT = 3
+
+// instructionNames are the first words of the instructions.
+var instructionNames = []string{
+	".db", ".dl", ".dw",
+	"alert", "alu", "await", "call", "coproc", "exit", "fetch", "if",
+	"io", "jump", "list", "return", "store", "trap", "vcall", "vjump", "write",
+}
+
+// listNames are the operations of the 'list' instruction.
+var listNames = []string{"all", "first", "next", "not", "of", "only", "write"}
+
+// ifNames are the comparisons of the 'if' instruction.
+var ifNames = []string{"eq?", "ne?", "lt?", "le?", "gt?", "ge?", "some?", "none?", "true?", "false?"}
+
+// ioNames are the operations of the 'io' instruction.
+var ioNames = []string{"alert", "await", "fetch", "store"}
+
+// distance returns the edit distance between two words, counting the
+// transposition of two adjacent letters as a single edit.
+func distance(a string, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+// suggest returns the known words most similar to an unknown word.
+func suggest(word string, known iter.Seq[string]) (suggest []string) {
+	limit := max(1, len(word)/3)
+
+	type candidate struct {
+		word     string
+		distance int
+	}
+	var candidates []candidate
+	for name := range known {
+		d := distance(strings.ToLower(word), strings.ToLower(name))
+		if d <= limit && name != word {
+			candidates = append(candidates, candidate{word: name, distance: d})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.word, b.word)
+	})
+
+	for _, c := range candidates[:min(len(candidates), SUGGEST_LIMIT)] {
+		suggest = append(suggest, c.word)
+	}
+	return
+}
+
+// unknownWord returns an ErrUnknown for an unknown word, with the known
+// words most similar to it.
+func unknownWord(err error, word string, known ...iter.Seq[string]) error {
+	return ErrUnknown{
+		Word: word,
+		Suggest: suggest(word, func(yield func(string) bool) {
+			for _, seq := range known {
+				for name := range seq {
+					if !yield(name) {
+						return
+					}
+				}
+			}
+		}),
+		Err: err,
+	}
+}
+
+// columnOf returns the column, from 1, of the word of a line named by an
+// error, or 0 if the error names no word of the line.
+func columnOf(line string, err error) (column int) {
+	var word string
+
+	var eu ErrUnknown
+	var ep ErrParseNumber
+	var el ErrLabelMissing
+	switch {
+	case errors.As(err, &eu):
+		word = eu.Word
+	case errors.As(err, &ep):
+		word = string(ep)
+	case errors.As(err, &el):
+		word = string(el)
+	default:
+		return
+	}
+
+	if len(word) == 0 {
+		return
+	}
+
+	start := -1
+	for n, c := range line {
+		if unicode.IsSpace(c) {
+			if start >= 0 && line[start:n] == word {
+				column = start + 1
+				return
+			}
+			start = -1
+		} else if start < 0 {
+			start = n
+		}
+	}
+	if start >= 0 && line[start:] == word {
+		column = start + 1
+		return
+	}
+
+	return
+}
@@ -0,0 +1,67 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"slices"
+	"strings"
+)
+
+// splitComment splits a line of source into its text and its comment,
+// without the leading ';'. commented is false if the line has no comment.
+func splitComment(line string) (text string, comment string, commented bool) {
+	text, comment, commented = strings.Cut(line, ";")
+	return
+}
+
+// splitWords splits the text of a line into its space separated words.
+func splitWords(text string) (words []string) {
+	for word := range strings.SplitSeq(text, " ") {
+		if len(word) != 0 {
+			words = append(words, word)
+		}
+	}
+	return
+}
+
+// canonicalWords returns the canonical spelling of the words of an
+// instruction: a '+' or '-' condition, and the 'CATEGORY ACTION' form of
+// the alternate syntaxes of the 'list', 'alu' and 'io' instructions.
+// isTarget reports if a word is a register that 'write' can target.
+func canonicalWords(words []string, isTarget func(word string) bool) (canon []string) {
+	if len(words) != 0 {
+		switch words[0] {
+		case "+", "?":
+			canon = append(canon, "+")
+			words = words[1:]
+		case "-", "!":
+			canon = append(canon, "-")
+			words = words[1:]
+		}
+	}
+
+	switch {
+	case len(words) >= 2 && words[0] == "write" && words[1] == "list":
+		// write list VALUE MASK => list write VALUE MASK
+		canon = append(canon, "list", "write")
+		words = words[2:]
+	case len(words) >= 2 && words[0] == "write" && words[1] == "first":
+		// write first VALUE MASK => list first VALUE MASK
+		canon = append(canon, "list", "first")
+		words = words[2:]
+	case len(words) >= 2 && words[0] == "write" && isTarget(words[1]):
+		// write <dst> VALUE MASK => alu set <dst> VALUE MASK
+		canon = append(canon, "alu", "set")
+		words = words[1:]
+	case len(words) == 1 && words[0] == "trap":
+		// trap => io await monitor
+		canon = append(canon, "io", "await", "monitor")
+		words = nil
+	case len(words) >= 1 && slices.Contains(ioNames, words[0]):
+		// fetch CHANNEL ... => io fetch CHANNEL ...
+		canon = append(canon, "io")
+	}
+
+	canon = append(canon, words...)
+	return
+}
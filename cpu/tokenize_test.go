@@ -0,0 +1,43 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWords(t *testing.T) {
+	assert := assert.New(t)
+
+	text, comment, commented := splitComment("  write  r0 1 ; one; two")
+	assert.Equal("  write  r0 1 ", text)
+	assert.Equal(" one; two", comment)
+	assert.True(commented)
+	assert.Equal([]string{"write", "r0", "1"}, splitWords(text))
+
+	_, _, commented = splitComment("write r0 1")
+	assert.False(commented)
+	assert.Empty(splitWords("   "))
+}
+
+func TestCanonicalWords(t *testing.T) {
+	assert := assert.New(t)
+
+	asm := &Assembler{}
+	asm.Clear()
+
+	// The alternate syntaxes assemble to the same codes as their
+	// canonical form.
+	err := asm.Parse(strings.NewReader("? exit\n+ exit\n! write r0 1\n- alu set r0 1\ntrap\nio await monitor\n"))
+	assert.NoError(err)
+	if assert.Len(asm.Opcode, 6) {
+		for n := 0; n < 6; n += 2 {
+			assert.Equal(asm.Opcode[n].Codes, asm.Opcode[n+1].Codes, "line %d", n+1)
+		}
+	}
+
+	asm.Clear()
+	err = asm.Parse(strings.NewReader("+\n"))
+	assert.ErrorIs(err, ErrOpcodeMissing)
+}
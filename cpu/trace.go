@@ -0,0 +1,284 @@
+package cpu
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// TRACE_VERSION is the version of the trace file format.
+const TRACE_VERSION = 1
+
+// Tracer records the execution of instructions.
+type Tracer interface {
+	// Trace is called after every executed instruction.
+	Trace(event *TraceEvent)
+}
+
+// TraceRegister is a register written by an instruction.
+type TraceRegister struct {
+	Name  string `json:"name"`  // Register name: r0..r5, match or mask.
+	Value uint32 `json:"value"` // New value of the register.
+}
+
+// TraceEvent is the record of a single executed instruction.
+type TraceEvent struct {
+	Ip          uint32          `json:"ip"`             // IP of the instruction.
+	Word        uint16          `json:"word"`           // Opcode word.
+	Immediates  []uint16        `json:"imm,omitempty"`  // Opcode immediates.
+	Next        uint32          `json:"next"`           // IP after the instruction.
+	Cond        bool            `json:"cond"`           // Condition flag after the instruction.
+	Register    []TraceRegister `json:"reg,omitempty"`  // Registers changed by the instruction.
+	Pop         int             `json:"pop,omitempty"`  // Values popped from the stack.
+	Push        []uint32        `json:"push,omitempty"` // Values pushed to the stack.
+	Count       uint            `json:"count"`          // CAPP matched cell count after the instruction.
+	First       uint32          `json:"first"`          // CAPP first matched cell after the instruction.
+	BitsFlipped int             `json:"flipped"`        // CAPP bits flipped by the instruction.
+	AluFlipped  int             `json:"alu,omitempty"`  // ALU bits flipped by the instruction.
+	Power       int             `json:"power"`          // Power consumed by the instruction.
+	Err         string          `json:"err,omitempty"`  // Error raised by the instruction.
+}
+
+// Code returns the code of the traced instruction.
+func (event *TraceEvent) Code() Code {
+	return Code{Word: event.Word, Immediates: event.Immediates}
+}
+
+// TraceState is the CPU state at a point in a trace.
+type TraceState struct {
+	Version  int       `json:"version"`  // Trace file format version.
+	Tick     int       `json:"tick"`     // Number of traced instructions executed.
+	Ip       uint32    `json:"ip"`       // Current instruction pointer.
+	Cond     bool      `json:"cond"`     // Current conditional execution state.
+	Register [6]uint32 `json:"register"` // Register bank.
+	Match    uint32    `json:"match"`    // Match value sent to the CAPP.
+	Mask     uint32    `json:"mask"`     // Mask value sent to the CAPP.
+	Stack    []uint32  `json:"stack"`    // Stack contents, bottom first.
+	Count    uint      `json:"count"`    // CAPP matched cell count.
+	First    uint32    `json:"first"`    // CAPP first matched cell.
+	Power    int       `json:"power"`    // Power counter.
+}
+
+// TraceState returns a snapshot of the current CPU state.
+func (cpu *Cpu) TraceState() (state TraceState) {
+	state = TraceState{
+		Version:  TRACE_VERSION,
+		Ip:       cpu.Ip,
+		Cond:     cpu.Cond,
+		Register: cpu.Register,
+		Match:    cpu.Match,
+		Mask:     cpu.Mask,
+		Stack:    slices.Clone(cpu.Stack.Data),
+		Count:    cpu.Capp.Count(),
+		First:    cpu.Capp.First(),
+		Power:    cpu.Power,
+	}
+
+	return
+}
+
+// traceEvent builds the trace event of an instruction, given the CPU state
+// before it was executed.
+func (cpu *Cpu) traceEvent(before *TraceState, code Code, err error) (event *TraceEvent) {
+	event = &TraceEvent{
+		Ip:          before.Ip,
+		Word:        code.Word,
+		Immediates:  code.Immediates,
+		Next:        cpu.Ip,
+		Cond:        cpu.Cond,
+		Count:       cpu.Capp.Count(),
+		First:       cpu.Capp.First(),
+		BitsFlipped: cpu.Capp.Flipped(),
+		AluFlipped:  cpu.aluFlipped,
+		Power:       cpu.Power - before.Power,
+	}
+
+	for n, value := range cpu.Register {
+		if value != before.Register[n] {
+			event.Register = append(event.Register, TraceRegister{Name: fmt.Sprintf("r%d", n), Value: value})
+		}
+	}
+	if cpu.Match != before.Match {
+		event.Register = append(event.Register, TraceRegister{Name: "match", Value: cpu.Match})
+	}
+	if cpu.Mask != before.Mask {
+		event.Register = append(event.Register, TraceRegister{Name: "mask", Value: cpu.Mask})
+	}
+
+	// Find the common bottom of the stack.
+	stack := cpu.Stack.Data
+	common := 0
+	for common < len(stack) && common < len(before.Stack) && stack[common] == before.Stack[common] {
+		common++
+	}
+	event.Pop = len(before.Stack) - common
+	event.Push = slices.Clone(stack[common:])
+
+	if err != nil {
+		event.Err = err.Error()
+	}
+
+	return
+}
+
+// Apply updates the state with a traced instruction.
+func (state *TraceState) Apply(event *TraceEvent) {
+	state.Tick++
+	state.Ip = event.Next
+	state.Cond = event.Cond
+	for _, reg := range event.Register {
+		switch reg.Name {
+		case "match":
+			state.Match = reg.Value
+		case "mask":
+			state.Mask = reg.Value
+		default:
+			var n int
+			_, err := fmt.Sscanf(reg.Name, "r%d", &n)
+			if err == nil && n >= 0 && n < len(state.Register) {
+				state.Register[n] = reg.Value
+			}
+		}
+	}
+	state.Stack = slices.Concat(state.Stack[:max(0, len(state.Stack)-event.Pop)], event.Push)
+	state.Count = event.Count
+	state.First = event.First
+	state.Power += event.Power
+}
+
+// MultiTracer is a Tracer that records to several Tracers.
+type MultiTracer []Tracer
+
+var _ Tracer = MultiTracer(nil)
+
+// Trace records an event to all the Tracers.
+func (mt MultiTracer) Trace(event *TraceEvent) {
+	for _, tracer := range mt {
+		tracer.Trace(event)
+	}
+}
+
+// TraceWriter is a Tracer that writes a trace file: the initial CPU state,
+// followed by one event per line, all encoded as JSON.
+type TraceWriter struct {
+	out *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+var _ Tracer = (*TraceWriter)(nil)
+
+// NewTraceWriter creates a trace file writer, starting from a CPU state.
+func NewTraceWriter(w io.Writer, state TraceState) (tw *TraceWriter) {
+	out := bufio.NewWriter(w)
+	tw = &TraceWriter{
+		out: out,
+		enc: json.NewEncoder(out),
+	}
+	tw.err = tw.enc.Encode(&state)
+
+	return
+}
+
+// Trace writes an event to the trace file.
+func (tw *TraceWriter) Trace(event *TraceEvent) {
+	if tw.err != nil {
+		return
+	}
+
+	tw.err = tw.enc.Encode(event)
+}
+
+// Flush writes any buffered events, and returns the first error
+// encountered while writing the trace file.
+func (tw *TraceWriter) Flush() (err error) {
+	if tw.err != nil {
+		err = tw.err
+		return
+	}
+
+	err = tw.out.Flush()
+	return
+}
+
+// TraceReader replays a trace file.
+type TraceReader struct {
+	State TraceState // CPU state after the events read so far.
+
+	in  io.ReadSeeker
+	dec *json.Decoder
+}
+
+// NewTraceReader opens a trace file for replay.
+func NewTraceReader(r io.ReadSeeker) (tr *TraceReader, err error) {
+	tr = &TraceReader{in: r}
+	err = tr.Rewind()
+	if err != nil {
+		tr = nil
+		return
+	}
+
+	return
+}
+
+// Rewind returns the replay to the start of the trace.
+func (tr *TraceReader) Rewind() (err error) {
+	_, err = tr.in.Seek(0, io.SeekStart)
+	if err != nil {
+		return
+	}
+
+	tr.dec = json.NewDecoder(tr.in)
+	var start TraceState
+	err = tr.dec.Decode(&start)
+	if err != nil {
+		return
+	}
+
+	if start.Version != TRACE_VERSION {
+		err = ErrTraceVersion
+		return
+	}
+
+	tr.State = start
+
+	return
+}
+
+// Next reads the next event of the trace, and applies it to the state.
+// Returns io.EOF at the end of the trace.
+func (tr *TraceReader) Next() (event *TraceEvent, err error) {
+	event = &TraceEvent{}
+	err = tr.dec.Decode(event)
+	if err != nil {
+		event = nil
+		return
+	}
+
+	tr.State.Apply(event)
+
+	return
+}
+
+// Seek replays the trace until the state is at a tick. Seeking backwards
+// replays the trace from its start. Returns io.EOF if the trace ends
+// before the tick.
+func (tr *TraceReader) Seek(tick int) (err error) {
+	if tick < tr.State.Tick {
+		err = tr.Rewind()
+		if err != nil {
+			return
+		}
+	}
+
+	for tr.State.Tick < tick {
+		_, err = tr.Next()
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,89 @@
+package cpu
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCpu_Tracer(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+	cpu.Ip = 0x100
+	cpu.Stack.Push(0x11)
+
+	var buff bytes.Buffer
+	tw := NewTraceWriter(&buff, cpu.TraceState())
+	cpu.Tracer = tw
+
+	codes := []Code{
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_REG_R2, IR_IMMEDIATE_16, 0x1234),
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_ADD, IR_STACK, IR_IMMEDIATE_16, 0x2),
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_SET, IR_STACK, IR_REG_R2),
+		MakeCodeCond(COND_ALWAYS, COND_OP_EQ, IR_REG_R2, IR_IMMEDIATE_16, 0x1234),
+		MakeCodeAlu(COND_FALSE, ALU_OP_SET, IR_REG_R3, IR_IMMEDIATE_16, 0x5678),
+		MakeCodeAlu(COND_ALWAYS, ALU_OP_ADD, IR_REG_R3, IR_STACK),
+		MakeCodeAlu(COND_NEVER, ALU_OP_SET, IR_REG_R3, IR_CONST_0),
+	}
+	var states []TraceState
+	for _, code := range codes {
+		_ = cpu.Execute(code)
+		states = append(states, cpu.TraceState())
+	}
+	assert.NoError(tw.Flush())
+
+	tr, err := NewTraceReader(bytes.NewReader(buff.Bytes()))
+	assert.NoError(err)
+	assert.Equal(0, tr.State.Tick)
+	assert.Equal(uint32(0x100), tr.State.Ip)
+	assert.Equal([]uint32{0x11}, tr.State.Stack)
+
+	for n, code := range codes {
+		event, err := tr.Next()
+		if !assert.NoError(err, "%d", n) {
+			return
+		}
+		assert.Equal(code, event.Code(), "%d", n)
+		assert.Equal(uint32(0x100+n), event.Ip, "%d", n)
+		assert.Equal(n+1, tr.State.Tick)
+		state := states[n]
+		state.Tick = n + 1
+		assert.Equal(state, tr.State, "%d", n)
+	}
+
+	_, err = tr.Next()
+	assert.ErrorIs(err, io.EOF)
+
+	// The skipped conditional is traced as its own code.
+	assert.NoError(tr.Seek(5))
+	assert.Equal(uint32(0), tr.State.Register[3])
+
+	// The failed opcode records its error.
+	assert.NoError(tr.Seek(6))
+	event, err := tr.Next()
+	assert.NoError(err)
+	assert.NotEmpty(event.Err)
+
+	// Seek backwards, and forwards.
+	assert.NoError(tr.Seek(2))
+	assert.Equal([]uint32{0x13}, tr.State.Stack)
+	assert.NoError(tr.Seek(3))
+	assert.Equal([]uint32{0x13, 0x1234}, tr.State.Stack)
+	assert.NoError(tr.Seek(6))
+	assert.Equal([]uint32{0x13}, tr.State.Stack)
+	assert.Equal(uint32(0x1234), tr.State.Register[3])
+
+	assert.ErrorIs(tr.Seek(100), io.EOF)
+	assert.Equal(len(codes), tr.State.Tick)
+}
+
+func TestTraceReader_Version(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewTraceReader(bytes.NewReader([]byte(`{"version":0}` + "\n")))
+	assert.ErrorIs(err, ErrTraceVersion)
+}
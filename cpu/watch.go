@@ -0,0 +1,58 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package cpu
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+// Watch adds a content watchpoint on the CAPP, and returns its identifier.
+// On the first watchpoint, the CAPP of the CPU is replaced by a watcher
+// of its changes. Triggered watchpoints are returned from Tick as an
+// ErrWatchpoint, after the instruction that triggered them.
+func (cpu *Cpu) Watch(wp capp.Watchpoint) (id int) {
+	if cpu.watcher == nil {
+		cpu.watcher = capp.NewWatcher(cpu.Capp)
+		cpu.Capp = cpu.watcher
+	}
+
+	id = cpu.watcher.Watch(wp)
+	return
+}
+
+// Unwatch removes a content watchpoint.
+func (cpu *Cpu) Unwatch(id int) (err error) {
+	if cpu.watcher == nil {
+		err = fmt.Errorf("%w: %d", capp.ErrWatchMissing, id)
+		return
+	}
+
+	err = cpu.watcher.Unwatch(id)
+	return
+}
+
+// Watchpoints returns an iter of the identifier and watchpoint of each
+// content watchpoint.
+func (cpu *Cpu) Watchpoints() iter.Seq2[int, capp.Watchpoint] {
+	return func(yield func(id int, wp capp.Watchpoint) bool) {
+		if cpu.watcher != nil {
+			cpu.watcher.Watchpoints(yield)
+		}
+	}
+}
+
+// checkWatch returns the watchpoints triggered by the last instruction.
+func (cpu *Cpu) checkWatch() (err error) {
+	if cpu.watcher == nil {
+		return
+	}
+
+	events := cpu.watcher.Events()
+	if len(events) != 0 {
+		err = ErrWatchpoint(events)
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+)
+
+func TestCpu_Watch(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCpu(64)
+	defer cpu.Close()
+
+	id := cpu.Watch(capp.Watchpoint{Kind: capp.WATCH_APPEAR, Match: 0x42, Mask: 0xff})
+	assert.Equal(1, id)
+
+	code := MakeCodeCapp(COND_ALWAYS, CAPP_OP_WRITE_FIRST, IR_REG_R1, IR_REG_R2)
+	cpu.Register[0] = uint32(code.Word)
+	cpu.Register[1] = 0x42
+	cpu.Register[2] = 0xffffffff
+	cpu.Ip = IP_MODE_REG
+
+	err := cpu.Tick()
+	assert.ErrorIs(err, ErrWatchpoint(nil))
+
+	var events ErrWatchpoint
+	if assert.True(errors.As(err, &events)) && assert.Len(events, 1) {
+		assert.Equal(id, events[0].Id)
+		assert.Equal(uint(0), events[0].Index)
+		assert.Equal(uint32(0x42), events[0].New.Data)
+	}
+
+	// Rewriting the cell does not make it appear again.
+	cpu.Ip = IP_MODE_REG
+	assert.NoError(cpu.Tick())
+
+	for id, wp := range cpu.Watchpoints() {
+		assert.Equal(1, id)
+		assert.Equal(capp.WATCH_APPEAR, wp.Kind)
+	}
+
+	assert.NoError(cpu.Unwatch(id))
+	assert.ErrorIs(cpu.Unwatch(id), capp.ErrWatchMissing)
+	assert.ErrorIs(NewCpu(8).Unwatch(id), capp.ErrWatchMissing)
+}
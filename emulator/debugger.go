@@ -0,0 +1,254 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package emulator
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// Debugger controls the execution of the emulator's program by
+// source line, with breakpoints.
+type Debugger struct {
+	*Emulator                  // Emulator being debugged.
+	Breakpoints map[int]string // Breakpoint IPs, and the location they were set by.
+}
+
+// NewDebugger creates a debugger for an emulator.
+func NewDebugger(emu *Emulator) (dbg *Debugger) {
+	dbg = &Debugger{
+		Emulator:    emu,
+		Breakpoints: map[int]string{},
+	}
+
+	return
+}
+
+// Resolve returns the IP of a location in the program.
+// A location is either a jump label, a source line number, or a
+// 'filename:lineno' pair. A line without code resolves to the next
+// line with code.
+func (dbg *Debugger) Resolve(location string) (ip int, err error) {
+	ip, ok := dbg.Program.Label[location]
+	if ok {
+		return
+	}
+
+	filename, line, found := strings.Cut(location, ":")
+	if !found {
+		filename, line = "", location
+	}
+
+	lineno, err := strconv.Atoi(line)
+	if err != nil {
+		err = ErrLocation(location)
+		return
+	}
+
+	var best *cpu.Opcode
+	for n, op := range dbg.Program.Opcodes {
+		if len(op.Codes) == 0 || op.LineNo < lineno {
+			continue
+		}
+		if len(filename) != 0 && op.Filename != filename {
+			continue
+		}
+		if best == nil || op.LineNo < best.LineNo {
+			best = &dbg.Program.Opcodes[n]
+		}
+	}
+
+	if best == nil {
+		err = ErrLocation(location)
+		return
+	}
+
+	ip = best.Ip
+	return
+}
+
+// Break sets a breakpoint at a location.
+func (dbg *Debugger) Break(location string) (ip int, err error) {
+	ip, err = dbg.Resolve(location)
+	if err != nil {
+		return
+	}
+
+	dbg.Breakpoints[ip] = location
+	return
+}
+
+// Clear removes the breakpoint at a location.
+func (dbg *Debugger) Clear(location string) (err error) {
+	ip, err := dbg.Resolve(location)
+	if err != nil {
+		return
+	}
+
+	_, ok := dbg.Breakpoints[ip]
+	if !ok {
+		err = ErrLocation(location)
+		return
+	}
+
+	delete(dbg.Breakpoints, ip)
+	return
+}
+
+// Watch sets a content watchpoint on the CAPP, described by its kind
+// ('appear', 'disappear', or 'change'), match, mask, and, for 'change',
+// the bits to watch.
+func (dbg *Debugger) Watch(spec ...string) (id int, err error) {
+	if len(spec) == 0 {
+		err = ErrWatchSyntax
+		return
+	}
+
+	var wp capp.Watchpoint
+	args := 2
+	switch spec[0] {
+	case "appear":
+		wp.Kind = capp.WATCH_APPEAR
+	case "disappear":
+		wp.Kind = capp.WATCH_DISAPPEAR
+	case "change":
+		wp.Kind = capp.WATCH_CHANGE
+		args = 3
+	default:
+		err = fmt.Errorf("%w: %v", ErrWatchSyntax, spec[0])
+		return
+	}
+
+	if len(spec) != args+1 {
+		err = fmt.Errorf("%w: %v", ErrWatchSyntax, strings.Join(spec, " "))
+		return
+	}
+
+	var value [3]uint32
+	for n, arg := range spec[1:] {
+		var v uint64
+		v, err = strconv.ParseUint(arg, 0, 32)
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrWatchSyntax, arg)
+			return
+		}
+		value[n] = uint32(v)
+	}
+	wp.Match, wp.Mask, wp.Bits = value[0], value[1], value[2]
+
+	id = dbg.Cpu.Watch(wp)
+	return
+}
+
+// Where returns the debugging information for the current IP.
+func (dbg *Debugger) Where() cpu.Debug {
+	return dbg.Program.Debug(uint16(dbg.Cpu.Ip & ^cpu.IP_MODE_MASK))
+}
+
+// Step executes a single instruction code.
+func (dbg *Debugger) Step() (done bool, err error) {
+	return dbg.Tick()
+}
+
+// Next executes all the instruction codes of the current source line.
+// A 'call' or 'vcall' is stepped over, unless a breakpoint is reached
+// before the call returns.
+func (dbg *Debugger) Next() (done bool, err error) {
+	where := dbg.Where()
+	if where.Opcode == nil {
+		return dbg.Step()
+	}
+
+	after := uint32(where.Ip + len(where.Codes))
+	depth := len(dbg.Cpu.Stack.Data)
+	stepOver := cpu.IsCall(where.Codes)
+
+	for {
+		done, err = dbg.Step()
+		if done || err != nil {
+			return
+		}
+
+		if dbg.Cpu.Ip == after && len(dbg.Cpu.Stack.Data) <= depth {
+			return
+		}
+
+		if !stepOver {
+			// Left the current source line, by a jump or a return.
+			current := dbg.Where()
+			if current.Opcode != where.Opcode || current.Index == 0 {
+				return
+			}
+			continue
+		}
+
+		if dbg.atBreakpoint() {
+			return
+		}
+	}
+}
+
+// Continue executes until the program is done, an error occurs, or
+// a breakpoint is reached.
+func (dbg *Debugger) Continue() (done bool, err error) {
+	for {
+		done, err = dbg.Step()
+		if done || err != nil {
+			return
+		}
+
+		if dbg.atBreakpoint() {
+			return
+		}
+	}
+}
+
+// StepBack restores the state before the last executed instruction code.
+func (dbg *Debugger) StepBack() (err error) {
+	return dbg.Emulator.StepBack(1)
+}
+
+// ReverseContinue steps back until a breakpoint is reached, or the start of
+// the history.
+func (dbg *Debugger) ReverseContinue() (err error) {
+	if dbg.History == nil {
+		err = ErrHistoryEmpty
+		return
+	}
+
+	n := 0
+	for ip := range dbg.History.Ips() {
+		n++
+		if dbg.isBreakpoint(ip) {
+			break
+		}
+	}
+
+	err = dbg.Emulator.StepBack(n)
+	return
+}
+
+// atBreakpoint returns true if the current IP has a breakpoint.
+func (dbg *Debugger) atBreakpoint() bool {
+	return dbg.isBreakpoint(dbg.Cpu.Ip)
+}
+
+// isBreakpoint returns true if an IP has a breakpoint.
+func (dbg *Debugger) isBreakpoint(ip uint32) bool {
+	if (ip & cpu.IP_MODE_MASK) != cpu.IP_MODE_CAPP {
+		return false
+	}
+
+	_, ok := dbg.Breakpoints[int(ip)]
+	return ok
+}
+
+// Active returns an iterator over the data of the active CAPP list.
+func (dbg *Debugger) Active() iter.Seq[uint32] {
+	return dbg.Cpu.Capp.List
+}
@@ -0,0 +1,298 @@
+package emulator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+var debuggerProgram = []string{
+	"jump Main",
+	"AddOne:",
+	"alu add r0 1",
+	"alu add r1 2",
+	"return",
+	"Main:",
+	"write r0 0x10",
+	"call AddOne",
+	"",
+	"write r2 0x30",
+	"call AddOne",
+	"write r3 0x40",
+}
+
+func doDebugLoad(emu *Emulator, program []string, t *testing.T) (dbg *Debugger) {
+	assert := assert.New(t)
+
+	asm := &cpu.Assembler{}
+	asm.Clear()
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+	emu.Program, err = asm.Link()
+	assert.NoError(err)
+
+	err = emu.Reset(cpu.CHANNEL_ID_MONITOR)
+	assert.NoError(err)
+
+	dbg = NewDebugger(emu)
+	return
+}
+
+func TestDebuggerResolve(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	dbg := doDebugLoad(emu, debuggerProgram, t)
+
+	ip, err := dbg.Resolve("AddOne")
+	assert.NoError(err)
+	assert.Equal(3, dbg.Program.Debug(uint16(ip)).LineNo)
+
+	ip, err = dbg.Resolve("9")
+	assert.NoError(err)
+	assert.Equal(10, dbg.Program.Debug(uint16(ip)).LineNo)
+
+	ip, err = dbg.Resolve(":7")
+	assert.NoError(err)
+	assert.Equal(7, dbg.Program.Debug(uint16(ip)).LineNo)
+
+	_, err = dbg.Resolve("Missing")
+	assert.ErrorIs(err, ErrLocation("Missing"))
+
+	_, err = dbg.Resolve("99")
+	assert.ErrorIs(err, ErrLocation("99"))
+
+	_, err = dbg.Resolve("other.uc:3")
+	assert.ErrorIs(err, ErrLocation("other.uc:3"))
+}
+
+func TestDebuggerBreak(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	dbg := doDebugLoad(emu, debuggerProgram, t)
+
+	_, err := dbg.Break("AddOne")
+	assert.NoError(err)
+	_, err = dbg.Break("12")
+	assert.NoError(err)
+
+	// First call of AddOne
+	done, err := dbg.Continue()
+	assert.NoError(err)
+	assert.False(done)
+	assert.Equal(3, dbg.Where().LineNo)
+	assert.Equal(uint32(0x10), emu.Cpu.Register[0])
+	assert.Equal(1, len(emu.Cpu.Stack.Data))
+
+	// Second call of AddOne
+	done, err = dbg.Continue()
+	assert.NoError(err)
+	assert.False(done)
+	assert.Equal(3, dbg.Where().LineNo)
+	assert.Equal(uint32(0x11), emu.Cpu.Register[0])
+	assert.Equal(uint32(0x30), emu.Cpu.Register[2])
+
+	err = dbg.Clear("AddOne")
+	assert.NoError(err)
+	err = dbg.Clear("AddOne")
+	assert.ErrorIs(err, ErrLocation("AddOne"))
+
+	done, err = dbg.Continue()
+	assert.NoError(err)
+	assert.False(done)
+	assert.Equal(12, dbg.Where().LineNo)
+
+	done, err = dbg.Continue()
+	assert.NoError(err)
+	assert.True(done)
+	assert.Equal(uint32(0x12), emu.Cpu.Register[0])
+	assert.Equal(uint32(0x40), emu.Cpu.Register[3])
+}
+
+func TestDebuggerStep(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	dbg := doDebugLoad(emu, debuggerProgram, t)
+
+	assert.Equal(1, dbg.Where().LineNo)
+
+	lines := []int{}
+	for {
+		done, err := dbg.Next()
+		assert.NoError(err)
+		if done || err != nil {
+			break
+		}
+		where := dbg.Where()
+		if where.Opcode != nil {
+			lines = append(lines, where.LineNo)
+		}
+	}
+
+	assert.Equal([]int{7, 8, 10, 11, 12}, lines)
+	assert.Equal(uint32(0x12), emu.Cpu.Register[0])
+
+	dbg = doDebugLoad(emu, debuggerProgram, t)
+
+	_, err := dbg.Break("8")
+	assert.NoError(err)
+	_, err = dbg.Continue()
+	assert.NoError(err)
+	assert.Equal(8, dbg.Where().LineNo)
+
+	// Step into the call, one code at a time.
+	for range 3 {
+		assert.Equal(8, dbg.Where().LineNo)
+		_, err = dbg.Step()
+		assert.NoError(err)
+	}
+	assert.Equal(3, dbg.Where().LineNo)
+
+	// Breakpoints stop a step over a call.
+	_, err = dbg.Break("4")
+	assert.NoError(err)
+	_, err = dbg.Next()
+	assert.NoError(err)
+	assert.Equal(4, dbg.Where().LineNo)
+	_, err = dbg.Next()
+	assert.NoError(err)
+	assert.Equal(5, dbg.Where().LineNo)
+	_, err = dbg.Next()
+	assert.NoError(err)
+	assert.Equal(10, dbg.Where().LineNo)
+	_, err = dbg.Next()
+	assert.NoError(err)
+	_, err = dbg.Next()
+	assert.NoError(err)
+	assert.Equal(4, dbg.Where().LineNo)
+}
+
+func TestDebuggerNext_Conditional(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	program := []string{
+		"jump Main",
+		"AddOne:",
+		"alu add r0 1",
+		"return",
+		"Main:",
+		"write r0 0",
+		"write r2 1",
+		"if eq? r0 0",
+		"+ call AddOne",
+		"if eq? r0 0",
+		"- call AddOne",
+		"+ vcall r2",
+		"write r1 r0",
+	}
+
+	dbg := doDebugLoad(emu, program, t)
+
+	// Conditional calls, taken or not, are stepped over.
+	lines := []int{}
+	for {
+		done, err := dbg.Next()
+		assert.NoError(err)
+		if done || err != nil {
+			break
+		}
+		where := dbg.Where()
+		if where.Opcode != nil {
+			lines = append(lines, where.LineNo)
+		}
+	}
+
+	assert.Equal([]int{6, 7, 8, 9, 10, 11, 12, 13}, lines)
+	assert.Equal(uint32(2), emu.Cpu.Register[1])
+}
+
+func TestDebuggerActive(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"list first 0x1234",
+		"list next",
+		"list first 0x1234",
+		"list of 0x1234",
+		"list all",
+		"exit",
+	}
+
+	dbg := doDebugLoad(emu, program, t)
+
+	_, err := dbg.Break("8")
+	assert.NoError(err)
+	_, err = dbg.Continue()
+	assert.NoError(err)
+
+	active := slices.Collect(dbg.Active())
+	assert.Equal([]uint32{0x1234, 0x1234}, active)
+}
+
+func TestDebuggerWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+	emu.EnableHistory(HISTORY_LIMIT)
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"list first 0x1234",
+		"list next",
+		"list first 0x1235",
+		"list of 0x1234",
+		"list all",
+		"exit",
+	}
+
+	dbg := doDebugLoad(emu, program, t)
+
+	_, err := dbg.Watch("appear", "0x1234", "0xfffe")
+	assert.NoError(err)
+	_, err = dbg.Watch("change")
+	assert.ErrorIs(err, ErrWatchSyntax)
+	_, err = dbg.Watch("vanish", "0", "0")
+	assert.ErrorIs(err, ErrWatchSyntax)
+	_, err = dbg.Watch("change", "0", "0", "x")
+	assert.ErrorIs(err, ErrWatchSyntax)
+
+	_, err = dbg.Continue()
+	assert.ErrorIs(err, cpu.ErrWatchpoint(nil))
+	assert.Equal(4, dbg.Where().LineNo)
+
+	_, err = dbg.Continue()
+	assert.ErrorIs(err, cpu.ErrWatchpoint(nil))
+	assert.Equal(6, dbg.Where().LineNo)
+
+	// Stepping back, and over the change again, triggers the watchpoint again.
+	assert.NoError(dbg.StepBack())
+	_, err = dbg.Continue()
+	assert.ErrorIs(err, cpu.ErrWatchpoint(nil))
+	assert.Equal(6, dbg.Where().LineNo)
+
+	done, err := dbg.Continue()
+	assert.NoError(err)
+	assert.True(done)
+}
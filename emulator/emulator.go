@@ -3,11 +3,15 @@
 package emulator
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"iter"
+	"log"
 	"maps"
 
+	"github.com/ezrec/ucapp/capp"
 	"github.com/ezrec/ucapp/cpu"
 	"github.com/ezrec/ucapp/internal"
 	"github.com/ezrec/ucapp/sio"
@@ -19,28 +23,66 @@ const (
 	CAPP_SIZE      = 8192 // 4K for program text, 1K for compiled, 3K for work
 )
 
-var _emulator_defines = map[string]string{
-	"CAPP_SIZE": fmt.Sprintf("%v", CAPP_SIZE),
-}
-
 // Emulator state. CPU + CAPP + IO channels.
 type Emulator struct {
 	Verbose  bool         // If set, enables verbose logging.
 	*cpu.Cpu              // Reference to the CPU simulation.
 	Program  *cpu.Program // Reference to the currently running program listing.
 
-	Temporary sio.Temporary // Temporary buffer IO channel.
-	Tape      sio.Tape      // Tape IO channel.
-	Depot     sio.Depot     // Depot (Drum and Ring) IO channel.
-	Rom       sio.Rom       // ROM IO channel.
+	Temporary sio.Temporary       // Temporary buffer IO channel.
+	Tape      sio.Tape            // Tape IO channel.
+	Depot     sio.Depot           // Depot (Drum and Ring) IO channel.
+	Vt        sio.VirtualTerminal // Virtual Terminal IO channel.
+	Rom       sio.Rom             // ROM IO channel.
 
 	TrapRequest chan uint32
+
+	History *History // If set, records each Tick so that it can be stepped back.
+}
+
+// Option is an option of NewEmulator.
+type Option func(config *config)
+
+// config is the configuration of NewEmulator.
+type config struct {
+	size      uint
+	newMemory func(count uint) capp.Memory
+}
+
+// WithCappSize sets the number of CAPP cells. Defaults to CAPP_SIZE.
+func WithCappSize(size uint) Option {
+	return func(config *config) {
+		config.size = size
+	}
+}
+
+// WithCapp sets the constructor of the CAPP memory model. Defaults to
+// capp.NewCapp.
+func WithCapp(newMemory func(count uint) capp.Memory) Option {
+	return func(config *config) {
+		config.newMemory = newMemory
+	}
 }
 
 // NewEmulator creates a new emulator.
-func NewEmulator() (emu *Emulator) {
+func NewEmulator(options ...Option) (emu *Emulator) {
+	config := config{
+		size:      CAPP_SIZE,
+		newMemory: func(count uint) capp.Memory { return capp.NewCapp(count) },
+	}
+	for _, option := range options {
+		option(&config)
+	}
+
+	emu = NewEmulatorWithCapp(config.newMemory(config.size))
+	return
+}
+
+// NewEmulatorWithCapp creates a new emulator, using a CAPP memory model.
+// The CAPP_SIZE define is the size of the memory.
+func NewEmulatorWithCapp(cp capp.Memory) (emu *Emulator) {
 	emu = &Emulator{
-		Cpu:     cpu.NewCpu(CAPP_SIZE),
+		Cpu:     cpu.NewCpuWithCapp(cp),
 		Program: &cpu.Program{},
 	}
 
@@ -50,6 +92,7 @@ func NewEmulator() (emu *Emulator) {
 	emu.Cpu.SetChannel(cpu.CHANNEL_ID_MONITOR, &emu.Rom)
 	emu.Cpu.SetChannel(cpu.CHANNEL_ID_TAPE, &emu.Tape)
 	emu.Cpu.SetChannel(cpu.CHANNEL_ID_DEPOT, &emu.Depot)
+	emu.Cpu.SetChannel(cpu.CHANNEL_ID_VT, &emu.Vt)
 
 	// Map the trap channel
 	_, emu.TrapRequest, _ = emu.Cpu.GetChannel(cpu.CHANNEL_ID_MONITOR)
@@ -60,12 +103,17 @@ func NewEmulator() (emu *Emulator) {
 
 // Defines returns an iterator over all of the defines
 func (emu *Emulator) Defines() iter.Seq2[string, string] {
-	return internal.IterSeq2Concat(maps.All(_emulator_defines),
+	defines := map[string]string{
+		"CAPP_SIZE": fmt.Sprintf("%v", emu.Cpu.Capp.Size()),
+	}
+
+	return internal.IterSeq2Concat(maps.All(defines),
 		emu.Cpu.Defines(),
 		emu.Temporary.Defines(),
 		emu.Rom.Defines(),
 		emu.Tape.Defines(),
 		emu.Depot.Defines(),
+		emu.Vt.Defines(),
 	)
 }
 
@@ -84,6 +132,14 @@ func (emu *Emulator) Reset(boot cpu.CodeChannel) (err error) {
 
 	emu.Rom.Data = emu.Program.Binary()
 
+	// Use the debug info of a ring booted from the depot.
+	if boot == cpu.CHANNEL_ID_DEPOT && emu.Depot.Drum != nil && emu.Depot.Drum.Ring != nil && len(emu.Depot.Drum.Ring.Debug) != 0 {
+		derr := emu.LoadDebug(emu.Depot.Drum.Ring)
+		if derr != nil && emu.Verbose {
+			log.Printf("debug info: %v", derr)
+		}
+	}
+
 	err = emu.Cpu.Reset(boot)
 	if err != nil {
 		return
@@ -98,13 +154,38 @@ func (emu *Emulator) Reset(boot cpu.CodeChannel) (err error) {
 	}
 
 	// Reset power stats.
-	cp.BitsFlipped = 0
+	cp.ClearFlipped()
+
+	if emu.History != nil {
+		emu.History.Clear()
+	}
 
 	emu.Cpu.Verbose = emu.Verbose
 
 	return
 }
 
+// LoadDebug sets the program listing from the debug info of a ring.
+// The debug info is only used if its program matches the ring's content.
+func (emu *Emulator) LoadDebug(ring *sio.Ring) (err error) {
+	prog, err := cpu.UnmarshalDebug(bytes.NewReader(ring.Debug))
+	if err != nil {
+		return
+	}
+
+	var data []byte
+	for _, word := range prog.Binary() {
+		data = binary.LittleEndian.AppendUint32(data, word)
+	}
+	if !bytes.Equal(data, ring.Data) {
+		err = ErrDebugMismatch
+		return
+	}
+
+	emu.Program = prog
+	return
+}
+
 // Ticks returns the total ticks since a reset.
 func (emu *Emulator) Ticks() int {
 	return emu.Cpu.Ticks
@@ -147,10 +228,20 @@ func (emu *Emulator) Tick() (done bool, err error) {
 	// Set CPU verbosity
 	emu.Cpu.Verbose = emu.Verbose
 
+	if emu.History != nil {
+		emu.History.record(emu.Cpu)
+	}
+
 	lineno := emu.LineNo()
+	where := emu.Program.Debug(uint16(emu.Cpu.Ip & ^cpu.IP_MODE_MASK))
 	defer func() {
 		if err != nil {
-			err = &ErrRuntime{LineNo: lineno, Err: err}
+			rerr := &ErrRuntime{LineNo: lineno, Err: err}
+			if lineno != 0 && where.Opcode != nil {
+				rerr.Filename = where.Filename
+				rerr.Macro = emu.Program.Expansion[where.Ip]
+			}
+			err = rerr
 		}
 	}()
 
@@ -160,6 +251,9 @@ func (emu *Emulator) Tick() (done bool, err error) {
 		if errors.Is(err, cpu.ErrIpEmpty) {
 			err = nil
 			done = true
+			if emu.History != nil {
+				emu.History.discard(emu.Cpu)
+			}
 			return
 		}
 		if err != nil {
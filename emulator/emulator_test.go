@@ -2,14 +2,37 @@ package emulator
 
 import (
 	"bytes"
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/ezrec/ucapp/capp"
 	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/sio"
 )
 
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/")
+
+// doCompareGolden compares output to the golden file testdata/<name>.golden.
+func doCompareGolden(name string, output string, t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		err := os.WriteFile(path, []byte(output), 0644)
+		assert.NoError(err)
+	}
+
+	golden, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(string(golden), output)
+}
+
 func TestEmulator(t *testing.T) {
 	assert := assert.New(t)
 
@@ -133,6 +156,71 @@ func TestEmulatorRegisters(t *testing.T) {
 	assert.Equal([]uint8{0x23, 0x01, 0x23, 0x09}, output)
 }
 
+func TestEmulatorWithCapp(t *testing.T) {
+	assert := assert.New(t)
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"fetch tape 0xffff",
+		"list not",
+		"write list ARENA_IO 0xffff0000",
+		"list of $(ARENA_IO | 0x123) $(ARENA_MASK | 0x7ff)",
+		"list all",
+		"store tape 0xffff",
+	}
+	input := []uint8{0x23, 0x00, 0x23, 0x01, 0x23, 0x09}
+
+	ref := NewEmulator()
+	defer ref.Close()
+	ref_output := doRunSingle(ref, program, input, t)
+
+	emu := NewEmulatorWithCapp(capp.NewBitPlane(CAPP_SIZE))
+	defer emu.Close()
+	output := doRunSingle(emu, program, input, t)
+
+	assert.Equal(ref_output, output)
+	assert.Equal(ref.Cpu.Capp.First(), emu.Cpu.Capp.First())
+	assert.Equal(ref.Cpu.Capp.Count(), emu.Cpu.Capp.Count())
+	assert.Equal(ref.Power(), emu.Power())
+	assert.Equal(ref.Ticks(), emu.Ticks())
+}
+
+func TestEmulatorCappSize(t *testing.T) {
+	assert := assert.New(t)
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"fetch tape 0xffff",
+		"list not",
+		"write list ARENA_IO 0xffff0000",
+		"list of $(ARENA_IO | 0x123) $(ARENA_MASK | 0x7ff)",
+		"list all",
+		"store tape 0xffff",
+	}
+	input := []uint8{0x23, 0x00, 0x23, 0x01, 0x23, 0x09}
+
+	ref := NewEmulator(WithCappSize(20000))
+	defer ref.Close()
+	ref_output := doRunSingle(ref, program, input, t)
+	assert.Equal("20000", maps.Collect(ref.Defines())["CAPP_SIZE"])
+
+	sharded := func(count uint) capp.Memory {
+		return capp.NewSharded(count, 4, func(count uint) capp.Memory { return capp.NewBitPlane(count) })
+	}
+	emu := NewEmulator(WithCappSize(20000), WithCapp(sharded))
+	defer emu.Close()
+	output := doRunSingle(emu, program, input, t)
+	assert.Equal("20000", maps.Collect(emu.Defines())["CAPP_SIZE"])
+
+	assert.Equal(ref_output, output)
+	assert.Equal(ref.Cpu.Capp.First(), emu.Cpu.Capp.First())
+	assert.Equal(ref.Cpu.Capp.Count(), emu.Cpu.Capp.Count())
+	assert.Equal(ref.Power(), emu.Power())
+	assert.Equal(ref.Ticks(), emu.Ticks())
+}
+
 func TestEmulatorAlu(t *testing.T) {
 	assert := assert.New(t)
 
@@ -314,3 +402,156 @@ func TestEmulatorTemp(t *testing.T) {
 
 	assert.Equal([]uint8{0x34, 0x92, 0x78, 0x96, 0xcd, 0x9b}, output)
 }
+
+func TestEmulatorVt(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"write first $((3 << 17) | (2 << 11) | 'H')",
+		"list next",
+		"write first $((4 << 17) | (2 << 11) | 'i')",
+		"list next",
+		"write first $((4 << 17) | (2 << 11) | (1 << 10) | (1 << 8) | 0x42)",
+		"list next",
+		"list not",
+		"store vt 0xffffff",
+		"list not",
+		"list of CAPP_FREE",
+		"list all",
+		"fetch vt 0xff",
+		"list not",
+		"store tape 0xff",
+		"list not",
+	}
+
+	emu.Vt.PushKey('o', 'k')
+
+	output := doRunSingle(emu, program, []byte{}, t)
+
+	assert.Equal([]byte("ok"), output)
+	assert.Equal(0, emu.Vt.Pending())
+
+	assert.Equal(uint8('H'), emu.Vt.Cell(2, 3).Glyph)
+	assert.False(emu.Vt.Cell(2, 3).Bold)
+
+	cell := emu.Vt.Cell(2, 4)
+	assert.Equal(uint8('i'), cell.Glyph)
+	assert.Equal(uint8(2), cell.Fg)
+	assert.Equal(uint8(4), cell.Bg)
+	assert.True(cell.Bold)
+	assert.False(cell.Italic)
+
+	assert.Equal(2, emu.Vt.Row)
+	assert.Equal(4, emu.Vt.Column)
+}
+
+func TestEmulatorVtScreen(t *testing.T) {
+	emu := NewEmulator()
+	defer emu.Close()
+
+	program := []string{
+		".macro PUTC row col glyph",
+		"write first $((col << 17) | (row << 11) | glyph)",
+		"list next",
+		".endm",
+		".macro ATTR row col attr",
+		"write first $((col << 17) | (row << 11) | (1 << 10) | attr)",
+		"list next",
+		".endm",
+		"list of CAPP_FREE",
+		"list all",
+		"PUTC 1 2 'H'",
+		"PUTC 1 3 'e'",
+		"PUTC 1 4 'y'",
+		"PUTC 1 5 '!'",
+		"ATTR 1 5 $((1 << 8) | 0x01)",
+		"PUTC 3 2 '>'",
+		"ATTR 3 2 $((1 << 9) | (4 << 4) | 0x0f)",
+		"list not",
+		"store vt 0xffffff",
+		"list not",
+		"list of CAPP_FREE",
+		"list all",
+		"list next",
+		"list not",
+		"write first $((4 << 17) | (3 << 11))",
+		"fetch vt 0xff",
+		"list of $((4 << 17) | (3 << 11)) 0xffffff00",
+		"list all",
+		"store vt 0xffffff",
+		"list not",
+	}
+
+	emu.Vt.TypeString("q")
+
+	doRunSingle(emu, program, []byte{}, t)
+
+	doCompareGolden("vt_screen", emu.Vt.Annotated(), t)
+}
+
+func TestEmulatorDebugInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator()
+	defer emu.Close()
+
+	program := []string{
+		".macro POP_R0",
+		"alu add r0 stack",
+		".endm",
+		"write r0 1",
+		"POP_R0",
+		"exit",
+	}
+
+	asm := &cpu.Assembler{}
+	asm.Clear()
+	err := asm.Parse(strings.NewReader(strings.Join(program, "\n")))
+	assert.NoError(err)
+	prog, err := asm.Link()
+	assert.NoError(err)
+
+	ring := &sio.Ring{}
+	ring.Rewind()
+	for _, item := range prog.Binary() {
+		sio.SendAsUint32(ring, item)
+	}
+	var debug bytes.Buffer
+	err = prog.MarshalDebug(&debug)
+	assert.NoError(err)
+	ring.Debug = debug.Bytes()
+
+	emu.Depot.Drums = map[uint32]*sio.Drum{
+		0: {Rings: map[uint8]*sio.Ring{0: ring}},
+	}
+	resp := make(chan uint32, 1)
+	defer close(resp)
+	emu.Depot.Alert(sio.DEPOT_OP_SELECT|0, resp)
+	assert.Equal(uint32(0), <-resp)
+	emu.Depot.Alert(sio.DEPOT_OP_DRUM|sio.DRUM_OP_SELECT|0, resp)
+	<-resp
+
+	err = emu.Reset(cpu.CHANNEL_ID_DEPOT)
+	assert.NoError(err)
+	assert.Equal(prog.Opcodes, emu.Program.Opcodes)
+
+	for done := false; !done && err == nil; {
+		done, err = emu.Tick()
+	}
+	var rerr *ErrRuntime
+	assert.ErrorAs(err, &rerr)
+	assert.ErrorIs(err, cpu.ErrStackEmpty)
+	assert.Equal("stdin", rerr.Filename)
+	assert.Equal(2, rerr.LineNo)
+	assert.Equal([]string{"POP_R0 stdin:5"}, rerr.Macro)
+
+	// Debug info for other content is not used.
+	ring.Data = ring.Data[:4]
+	err = emu.LoadDebug(ring)
+	assert.ErrorIs(err, ErrDebugMismatch)
+}
@@ -1,21 +1,48 @@
 package emulator
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/ezrec/ucapp/translate"
 )
 
 var f = translate.From
 
+var (
+	ErrDebugMismatch = errors.New(f("debug info does not match the ring"))
+	ErrStateVersion  = errors.New(f("save state version unsupported"))
+	ErrStateMissing  = errors.New(f("save state incomplete"))
+	ErrHistoryEmpty  = errors.New(f("history empty"))
+	ErrWatchSyntax   = errors.New(f("watchpoint syntax"))
+)
+
 // ErrRuntime indicates the location of a runtime error.
 type ErrRuntime struct {
-	LineNo int
-	Err    error
+	Filename string   // Source file name, if known.
+	LineNo   int      // Source line number, or zero if unknown.
+	Macro    []string // Macro invocations of the source line, innermost first.
+	Err      error
 }
 
 func (err *ErrRuntime) Error() string {
-	return f("line %d %v", err.LineNo, err.Err)
+	text := f("line %d %v", err.LineNo, err.Err)
+	if len(err.Filename) != 0 {
+		text = f("%v:%d %v", err.Filename, err.LineNo, err.Err)
+	}
+	if len(err.Macro) != 0 {
+		text += f(" (in %v)", strings.Join(err.Macro, ", in "))
+	}
+	return text
 }
 
 func (err *ErrRuntime) Unwrap() error {
 	return err.Err
 }
+
+// ErrLocation indicates a breakpoint location that is not in the program.
+type ErrLocation string
+
+func (err ErrLocation) Error() string {
+	return f("location %v not found", string(err))
+}
@@ -0,0 +1,121 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package emulator
+
+import (
+	"iter"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+// HISTORY_LIMIT is the default number of steps kept by the history.
+const HISTORY_LIMIT = 10000
+
+// historyStep is the state before a single Tick of the emulator.
+type historyStep struct {
+	cpu  cpu.CpuSnapshot // CPU registers.
+	mark int             // CAPP journal mark.
+}
+
+// History is a bounded history of the CPU and CAPP changes made by each
+// Tick of the emulator, so that execution can be stepped backwards.
+//
+// The CAPP changes are recorded by a capp.Journal, which keeps the prior
+// content of only the cells changed by each action. Channel IO is not
+// recorded: stepping back over an 'io' instruction does not un-read or
+// un-write its channel.
+type History struct {
+	Limit int // Maximum number of steps kept.
+
+	journal *capp.Journal
+	steps   []historyStep
+}
+
+// EnableHistory starts recording a history of up to limit steps. The CAPP
+// of the CPU is replaced by a journal of its changes.
+func (emu *Emulator) EnableHistory(limit int) {
+	journal, ok := emu.Cpu.Capp.(*capp.Journal)
+	if !ok {
+		journal = capp.NewJournal(emu.Cpu.Capp)
+		emu.Cpu.Capp = journal
+	}
+
+	emu.History = &History{
+		Limit:   limit,
+		journal: journal,
+	}
+}
+
+// Len returns the number of steps in the history.
+func (hist *History) Len() int {
+	return len(hist.steps)
+}
+
+// Ips returns an iterator over the IP before each step, most recent first.
+func (hist *History) Ips() iter.Seq[uint32] {
+	return func(yield func(ip uint32) bool) {
+		for n := len(hist.steps) - 1; n >= 0; n-- {
+			if !yield(hist.steps[n].cpu.Ip) {
+				return
+			}
+		}
+	}
+}
+
+// Clear discards all of the steps.
+func (hist *History) Clear() {
+	hist.steps = nil
+	hist.journal.Forget(hist.journal.Mark())
+}
+
+// record appends the state before a step, and discards the oldest step
+// if the history is full.
+func (hist *History) record(c *cpu.Cpu) {
+	hist.steps = append(hist.steps, historyStep{
+		cpu:  c.Snapshot(),
+		mark: hist.journal.Mark(),
+	})
+
+	if len(hist.steps) > max(hist.Limit, 1) {
+		hist.steps = hist.steps[1:]
+		hist.journal.Forget(hist.steps[0].mark)
+	}
+}
+
+// discard drops the most recent step, if it changed nothing.
+func (hist *History) discard(c *cpu.Cpu) {
+	last := len(hist.steps) - 1
+	if last >= 0 && hist.steps[last].mark == hist.journal.Mark() && hist.steps[last].cpu.Ip == c.Ip {
+		hist.steps = hist.steps[:last]
+	}
+}
+
+// back restores the state before the n most recent steps.
+func (hist *History) back(c *cpu.Cpu, n int) (err error) {
+	if n < 1 || n > len(hist.steps) {
+		err = ErrHistoryEmpty
+		return
+	}
+
+	step := hist.steps[len(hist.steps)-n]
+	err = hist.journal.Rollback(step.mark)
+	if err != nil {
+		return
+	}
+
+	c.Restore(step.cpu)
+	hist.steps = hist.steps[:len(hist.steps)-n]
+	return
+}
+
+// StepBack restores the state before the n most recent Ticks.
+func (emu *Emulator) StepBack(n int) (err error) {
+	if emu.History == nil {
+		err = ErrHistoryEmpty
+		return
+	}
+
+	err = emu.History.back(emu.Cpu, n)
+	return
+}
@@ -0,0 +1,135 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/capp"
+	"github.com/ezrec/ucapp/cpu"
+)
+
+var historyProgram = []string{
+	"jump Main",
+	"AddOne:",
+	"alu add r0 1",
+	"list first r0",
+	"list next",
+	"return",
+	"Main:",
+	"list of CAPP_FREE",
+	"list all",
+	"write r0 0x10",
+	"call AddOne",
+	"call AddOne",
+	"write r3 0x40",
+}
+
+// historyState is the CPU and CAPP state at a step.
+type historyState struct {
+	Cpu  cpu.TraceState
+	Capp []capp.Cell
+}
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator(WithCappSize(512))
+	defer emu.Close()
+	emu.EnableHistory(HISTORY_LIMIT)
+
+	dbg := doDebugLoad(emu, historyProgram, t)
+	assert.Equal(0, emu.History.Len())
+
+	state := func() historyState {
+		return historyState{Cpu: emu.Cpu.TraceState(), Capp: emu.Cpu.Capp.Export()}
+	}
+
+	states := []historyState{state()}
+	for {
+		done, err := dbg.Step()
+		assert.NoError(err)
+		if done || err != nil {
+			break
+		}
+		states = append(states, state())
+	}
+	assert.Equal(uint32(0x12), emu.Cpu.Register[0])
+	assert.Equal(len(states)-1, emu.History.Len())
+
+	for n := len(states) - 2; n >= 0; n-- {
+		assert.NoError(dbg.StepBack())
+		assert.Equal(states[n], state(), "step %d", n)
+	}
+	assert.ErrorIs(dbg.StepBack(), ErrHistoryEmpty)
+
+	// Execution resumes from the stepped back state.
+	done, err := dbg.Continue()
+	assert.NoError(err)
+	assert.True(done)
+	assert.Equal(states[len(states)-1], state())
+}
+
+func TestHistory_ReverseContinue(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator(WithCappSize(512))
+	defer emu.Close()
+	emu.EnableHistory(HISTORY_LIMIT)
+
+	dbg := doDebugLoad(emu, historyProgram, t)
+	done, err := dbg.Continue()
+	assert.NoError(err)
+	assert.True(done)
+
+	_, err = dbg.Break("AddOne")
+	assert.NoError(err)
+
+	// Back to the second call of AddOne.
+	assert.NoError(dbg.ReverseContinue())
+	assert.Equal(3, dbg.Where().LineNo)
+	assert.Equal(uint32(0x11), emu.Cpu.Register[0])
+	count := emu.Cpu.Capp.Count()
+
+	// Back to the first call of AddOne.
+	assert.NoError(dbg.ReverseContinue())
+	assert.Equal(3, dbg.Where().LineNo)
+	assert.Equal(uint32(0x10), emu.Cpu.Register[0])
+	assert.Equal(count+1, emu.Cpu.Capp.Count())
+
+	// Back to the start of the history.
+	assert.NoError(dbg.ReverseContinue())
+	assert.Equal(1, dbg.Where().LineNo)
+	assert.ErrorIs(dbg.ReverseContinue(), ErrHistoryEmpty)
+}
+
+func TestHistory_Limit(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator(WithCappSize(512))
+	defer emu.Close()
+	emu.EnableHistory(3)
+
+	dbg := doDebugLoad(emu, historyProgram, t)
+	for range 6 {
+		_, err := dbg.Step()
+		assert.NoError(err)
+	}
+	assert.Equal(3, emu.History.Len())
+
+	assert.ErrorIs(emu.StepBack(4), ErrHistoryEmpty)
+	assert.NoError(emu.StepBack(3))
+	assert.Equal(0, emu.History.Len())
+
+	// A reset clears the history.
+	_, err := dbg.Step()
+	assert.NoError(err)
+	assert.NoError(emu.Reset(cpu.CHANNEL_ID_MONITOR))
+	assert.Equal(0, emu.History.Len())
+
+	// Without a history, there is nothing to step back.
+	other := NewEmulator(WithCappSize(512))
+	defer other.Close()
+	assert.ErrorIs(other.StepBack(1), ErrHistoryEmpty)
+	assert.ErrorIs(NewDebugger(other).ReverseContinue(), ErrHistoryEmpty)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ezrec/ucapp/cpu"
+	"github.com/ezrec/ucapp/sio"
+)
+
+// SAVE_STATE_VERSION is the version of the save-state file format.
+const SAVE_STATE_VERSION = 1
+
+// State is the saved state of the emulator: the CPU, its CAPP, and the
+// state of every IO channel. The program, and the content of the depot,
+// are not saved.
+type State struct {
+	Version   int                 `json:"version"`   // Save-state file format version.
+	Cpu       *cpu.CpuState       `json:"cpu"`       // CPU and CAPP state.
+	Temporary *sio.TemporaryState `json:"temporary"` // Temporary buffer state.
+	Tape      *sio.TapeState      `json:"tape"`      // Tape state.
+	Depot     *sio.DepotState     `json:"depot"`     // Selected drum and ring, and ring positions.
+	Vt        *sio.VtState        `json:"vt"`        // Virtual Terminal state.
+}
+
+// SaveState returns the state of the emulator.
+func (emu *Emulator) SaveState() (state *State) {
+	state = &State{
+		Version:   SAVE_STATE_VERSION,
+		Cpu:       emu.Cpu.SaveState(),
+		Temporary: emu.Temporary.SaveState(),
+		Tape:      emu.Tape.SaveState(),
+		Depot:     emu.Depot.SaveState(),
+		Vt:        emu.Vt.SaveState(),
+	}
+	return
+}
+
+// LoadState restores the state of the emulator.
+func (emu *Emulator) LoadState(state *State) (err error) {
+	if state.Version != SAVE_STATE_VERSION {
+		err = fmt.Errorf("%w: %v", ErrStateVersion, state.Version)
+		return
+	}
+
+	if state.Cpu == nil || state.Temporary == nil || state.Tape == nil || state.Depot == nil || state.Vt == nil {
+		err = ErrStateMissing
+		return
+	}
+
+	err = emu.Cpu.LoadState(state.Cpu)
+	if err != nil {
+		return
+	}
+
+	err = emu.Temporary.LoadState(state.Temporary)
+	if err != nil {
+		return
+	}
+
+	err = emu.Tape.LoadState(state.Tape)
+	if err != nil {
+		return
+	}
+
+	err = emu.Depot.LoadState(state.Depot)
+	if err != nil {
+		return
+	}
+
+	err = emu.Vt.LoadState(state.Vt)
+	if err != nil {
+		return
+	}
+
+	if emu.History != nil {
+		emu.History.Clear()
+	}
+
+	return
+}
+
+// MarshalState writes the state of the emulator as JSON.
+func (emu *Emulator) MarshalState(w io.Writer) (err error) {
+	err = json.NewEncoder(w).Encode(emu.SaveState())
+	return
+}
+
+// UnmarshalState restores the state of the emulator from JSON.
+func (emu *Emulator) UnmarshalState(r io.Reader) (err error) {
+	var state State
+	err = json.NewDecoder(r).Decode(&state)
+	if err != nil {
+		return
+	}
+
+	err = emu.LoadState(&state)
+	return
+}
@@ -0,0 +1,105 @@
+package emulator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ezrec/ucapp/cpu"
+)
+
+func TestEmulatorState(t *testing.T) {
+	assert := assert.New(t)
+
+	program := []string{
+		"list of CAPP_FREE",
+		"list all",
+		"fetch tape 0xffff",
+		"list not",
+		"write list ARENA_IO 0xffff0000",
+		"list of ARENA_IO ARENA_MASK",
+		"store temp 0xffff",
+		"list not",
+		"write list CAPP_FREE",
+		"fetch temp 0xffff",
+		"list not",
+		"write list 0x9000 0xf000",
+		"store tape 0xffff",
+		"list not",
+	}
+
+	start := func(input []byte, output *bytes.Buffer) (emu *Emulator) {
+		emu = NewEmulator(WithCappSize(1024))
+
+		asm := &cpu.Assembler{}
+		asm.Clear()
+		assert.NoError(asm.Parse(strings.NewReader(strings.Join(program, "\n"))))
+		var err error
+		emu.Program, err = asm.Link()
+		assert.NoError(err)
+		assert.NoError(emu.Reset(cpu.CHANNEL_ID_MONITOR))
+
+		emu.Tape.Input = bytes.NewReader(input)
+		emu.Tape.Output = output
+		return
+	}
+
+	run := func(emu *Emulator, until int) {
+		for emu.LineNo() != until {
+			done, err := emu.Tick()
+			if !assert.NoError(err) || done {
+				return
+			}
+		}
+	}
+
+	// Stop after the tape has been read into the temporary buffer.
+	var output bytes.Buffer
+	emu := start([]byte{0x34, 0x12, 0x78, 0x56}, &output)
+	defer emu.Close()
+	run(emu, 8)
+	assert.Equal(32, emu.Temporary.Size)
+
+	var saved bytes.Buffer
+	assert.NoError(emu.MarshalState(&saved))
+
+	resumed := start(nil, &output)
+	defer resumed.Close()
+	assert.NoError(resumed.UnmarshalState(bytes.NewReader(saved.Bytes())))
+	assert.Equal(emu.Cpu.Ip, resumed.Cpu.Ip)
+	assert.Equal(emu.Power(), resumed.Power())
+	assert.Equal(emu.Ticks(), resumed.Ticks())
+
+	run(resumed, 0)
+	assert.Equal([]byte{0x34, 0x92, 0x78, 0x96}, output.Bytes())
+
+	// The uninterrupted run gives the same result.
+	var direct bytes.Buffer
+	emu = start([]byte{0x34, 0x12, 0x78, 0x56}, &direct)
+	defer emu.Close()
+	run(emu, 0)
+	assert.Equal(direct.Bytes(), output.Bytes())
+	assert.Equal(emu.Power(), resumed.Power())
+	assert.Equal(emu.Ticks(), resumed.Ticks())
+}
+
+func TestEmulatorState_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	emu := NewEmulator(WithCappSize(256))
+	defer emu.Close()
+
+	state := emu.SaveState()
+	state.Version = SAVE_STATE_VERSION + 1
+	assert.ErrorIs(emu.LoadState(state), ErrStateVersion)
+
+	state = emu.SaveState()
+	state.Vt = nil
+	assert.ErrorIs(emu.LoadState(state), ErrStateMissing)
+
+	other := NewEmulator(WithCappSize(128))
+	defer other.Close()
+	assert.ErrorIs(other.LoadState(emu.SaveState()), cpu.ErrStateCapp)
+}
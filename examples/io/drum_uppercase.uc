@@ -9,33 +9,33 @@
 .equ RING_OP_REWIND_WRITE 1
 
 .macro DEPOT_IN8 drum ring
-; Select drum
-alert depot $(DEPOT_OP_SELECT | drum)
-await depot r0
-; Select ring
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | ring)
-await depot r0
-; Reset read pointer of ring
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_READ)
-await depot r0
-list of CAPP_FREE
-list all
-fetch depot 0xff
-list not
-list write ARENA_IO ARENA_MASK
+    ; Select drum
+    io alert depot $(DEPOT_OP_SELECT | drum)
+    io await depot r0
+    ; Select ring
+    io alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | ring)
+    io await depot r0
+    ; Reset read pointer of ring
+    io alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_READ)
+    io await depot r0
+    list of CAPP_FREE
+    list all
+    io fetch depot 0xff
+    list not
+    list write ARENA_IO ARENA_MASK
 .endm
 
 .macro DEPOT_OUT8 drum ring
-; Select drum
-alert depot $(DEPOT_OP_SELECT | drum)
-await depot r0
-; Select ring
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | ring)
-await depot r0
-; Reset write pointer of ring
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_WRITE)
-await depot r0
-store depot 0xff
+    ; Select drum
+    io alert depot $(DEPOT_OP_SELECT | drum)
+    io await depot r0
+    ; Select ring
+    io alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | ring)
+    io await depot r0
+    ; Reset write pointer of ring
+    io alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_WRITE)
+    io await depot r0
+    io store depot 0xff
 .endm
 
 DEPOT_IN8 1 2
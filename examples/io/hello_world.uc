@@ -2,33 +2,33 @@
 
 list of CAPP_FREE
 list all
-write first 'H'
+list first 'H'
 list next
-write first 'e'
+list first 'e'
 list next
-write first 'l'
+list first 'l'
 list next
-write first 'l'
+list first 'l'
 list next
-write first 'o'
+list first 'o'
 list next
-write first ' '
+list first ' '
 list next
-write first 'W'
+list first 'W'
 list next
-write first 'o'
+list first 'o'
 list next
-write first 'r'
+list first 'r'
 list next
-write first 'l'
+list first 'l'
 list next
-write first 'd'
+list first 'd'
 list next
-write first '!'
+list first '!'
 list next
-write first '\n'
+list first '\n'
 list next
 list not
-store tape 0xff
+io store tape 0xff
 list not
-write list ~0 ~0
+list write ~0 ~0
@@ -0,0 +1,66 @@
+; Longest prefix match (LPM) on a ternary CAPP. Each route is a 16 bit
+; prefix and an 8 bit next hop, and each route cell only cares about the
+; bits of its prefix. The routes are written longest prefix first, so the
+; first matching route is the longest prefix.
+;
+; Reads 16 bit addresses (low byte first) from the input tape, and writes
+; the next hop of each to the output tape (0xff if there is no route).
+;
+; Requires the ternary coprocessor:
+;   ucapp --coproc cp0=ternary build lpm.uc
+
+.equ HOP_SHIFT 16
+.equ DONE $(1 << 24)
+
+; ROUTE PREFIX BITS HOP: Add a route for the top BITS of PREFIX.
+.macro ROUTE PREFIX BITS HOP
+    list first $(ARENA_DATA | (HOP << HOP_SHIFT) | PREFIX) ~0
+    coproc cp0 $(TERNARY_FIRST | (TERNARY_CARE_MASK & ~((1 << (16 - BITS)) - 1)))
+    list next
+.endm
+
+list of CAPP_FREE
+list all
+ROUTE 0x1234 16 1
+ROUTE 0x1230 12 2
+ROUTE 0x1200 8 3
+ROUTE 0x1000 4 4
+
+; Load the addresses into the IO arena.
+list of CAPP_FREE
+list all
+io fetch tape 0xffff
+list not
+list write ARENA_IO 0xffff0000
+
+Next:
+list of ARENA_IO $(ARENA_MASK | DONE)
+list all
+if none?
++ jump Done
+alu set r0 first
+
+; Find the routes matching the address; the first is the longest prefix.
+alu and r0 0xffff
+alu or r0 ARENA_DATA
+list of r0 $(ARENA_MASK | 0xffff)
+list all
+alu set r1 0xff
+if some?
++ alu set r1 first
++ alu shr r1 HOP_SHIFT
+alu and r1 0xff
+alu or r1 DONE
+
+; Record the next hop in the address cell.
+list of ARENA_IO $(ARENA_MASK | DONE)
+list all
+list first r1 $(DONE | 0xffff)
+jump Next
+
+Done:
+list of ARENA_IO ARENA_MASK
+list all
+io store tape 0xff
+list write ~0 ~0
+exit
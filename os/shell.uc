@@ -12,20 +12,20 @@ PROMPT:
 ; Dump temporary
 list of CAPP_FREE
 list all
-fetch temp
+io fetch temp
 list not
-write list CAPP_FREE
+list write CAPP_FREE
 ; Print the shell prompt.
 list of CAPP_FREE
 list all
-write first 0x617264 ; 'dra'
+list first 0x617264 ; 'dra'
 list next
-write first 0x203e74 ; 't> '
+list first 0x203e74 ; 't> '
 list next
 list not
-store tape 0xffffff
+io store tape 0xffffff
 list not
-write list ~0
+list write ~0
 
 ; Read command from command line
 ; (first 4 bytes or until a space is seen)
@@ -33,35 +33,35 @@ alu set r0 0
 list of CAPP_FREE
 list all
 list next
-list not    ; Only one word is allocated in the list.
-write first ARENA_IO ARENA_MASK
+list not ; Only one word is allocated in the list.
+list first ARENA_IO ARENA_MASK
 list of ARENA_IO ARENA_MASK
 
 NEXT_LETTER:
 list all
-write first 0
-fetch tape 0xff
+list first 0
+io fetch tape 0xff
 list not
 if none?
-+ exit  ; FIXME: how to best exit?
++ exit ; FIXME: how to best exit?
 + jump NEXT_LETTER
-if eq? first ' ' ; command complete?
+if eq? first ' '    ; command complete?
 - if eq? first '\n' ; command complete?
 - if eq? first '\r' ; command complete?
 - alu shl r0 8
-- write first r0 0xffffff00
+- list first r0 0xffffff00
 - alu set r0 first
 - jump NEXT_LETTER
 
 ; Write remainder of command line to TEMPORARY channel
-write first 0 0xffffff00
+list first 0 0xffffff00
 NEXT_COMMAND:
-if eq? first '\n' ; command complete?
+if eq? first '\n'   ; command complete?
 - if eq? first '\r' ; command complete?
-- fetch tape 0xff
+- io fetch tape 0xff
 - list not
 - if none?
-- store temp 0xff
+- io store temp 0xff
 - list not
 - jump NEXT_COMMAND
 
@@ -69,13 +69,13 @@ call OsLibConvert8To6
 
 ; Find command (in r0) in current drum's Ring 0xff directory
 LOAD_RING:
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | 0xff)
-await depot
-alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_READ)
-await depot
+io alert depot $(DEPOT_OP_DRUM | DRUM_OP_SELECT | 0xff)
+io await depot
+io alert depot $(DEPOT_OP_DRUM | DRUM_OP_RING | RING_OP_REWIND_READ)
+io await depot
 list of CAPP_FREE
 list all
-fetch depot
+io fetch depot
 list not
 list only r0 0x00ffffff
 if none?
@@ -88,8 +88,8 @@ alu set r0 first
 list write CAPP_FREE
 alu shr r0 24
 alu or r0 $(DEPOT_OP_DRUM | DEPOT_OP_SELECT)
-alert depot r0
-await depot r0
+io alert depot r0
+io await depot r0
 if eq? r0 ~0
 + jump PROMPT
 
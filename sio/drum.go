@@ -54,16 +54,17 @@ func (drum *Drum) Rewind() {
 }
 
 // Unmarshal loads drum data from a file system by scanning for ring files
-// matching the pattern XX.ur (2 hex digits).
+// matching the pattern XX.ur (2 hex digits), and their XX.urd debug info.
 func (drum *Drum) Unmarshal(filesys fs.FS) (err error) {
 	drum.Rings = map[uint8](*Ring){}
 
-	return fs.WalkDir(filesys, ".", func(path string, d fs.DirEntry, err_in error) (err error) {
+	debug := map[uint8][]uint8{}
+	err = fs.WalkDir(filesys, ".", func(path string, d fs.DirEntry, err_in error) (err error) {
 		if d.IsDir() {
 			return
 		}
 		name := d.Name()
-		ok, err := regexp.MatchString("(?i)[0-9a-f][0-9a-f].ur", name)
+		ok, err := regexp.MatchString("(?i)^[0-9a-f][0-9a-f]\\.urd?$", name)
 		if err != nil {
 			return
 		}
@@ -76,6 +77,11 @@ func (drum *Drum) Unmarshal(filesys fs.FS) (err error) {
 			return
 		}
 
+		if strings.EqualFold(filepath.Ext(name), ".urd") {
+			debug[uint8(ring_index)], err = fs.ReadFile(filesys, name)
+			return
+		}
+
 		// Ensure the ring exists, and unmarshal it.
 		ring := &Ring{}
 		ring.Rewind()
@@ -90,10 +96,19 @@ func (drum *Drum) Unmarshal(filesys fs.FS) (err error) {
 
 		return
 	})
+
+	for index, data := range debug {
+		ring, ok := drum.Rings[index]
+		if ok {
+			ring.Debug = data
+		}
+	}
+
+	return
 }
 
 // Marshal writes the drum's rings to a file system, creating files named
-// XX.ur for each ring.
+// XX.ur for each ring, and XX.urd for each ring's debug info.
 func (drum *Drum) Marshal(filesys CreateFS) (err error) {
 	for index, ring := range drum.Rings {
 		if !ring.Dirty() {
@@ -112,6 +127,23 @@ func (drum *Drum) Marshal(filesys CreateFS) (err error) {
 		if err != nil {
 			return
 		}
+
+		if len(ring.Debug) == 0 {
+			continue
+		}
+
+		debug_name := fmt.Sprintf("%02x.urd", index)
+		var debug_file io.WriteCloser
+		debug_file, err = filesys.Create(debug_name)
+		if err != nil {
+			return
+		}
+
+		_, err = debug_file.Write(ring.Debug)
+		debug_file.Close()
+		if err != nil {
+			return
+		}
 	}
 
 	return
@@ -333,6 +365,7 @@ func (drum *Drum) Save(name string, content io.Reader) (err error) {
 		return
 	}
 
+	ring.Debug = nil
 	ring.isDirty = true
 	ring.ReadIndex = 0
 	ring.WriteIndex = len(ring.Data) * 8
@@ -1,8 +1,12 @@
 package sio
 
 import (
+	"bytes"
+	"io"
+	"io/fs"
 	"iter"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -72,3 +76,79 @@ func TestDrum(t *testing.T) {
 
 	assert.Equal(expecting, drum.Rings)
 }
+
+// memCreateFS is an in-memory CreateFS.
+type memCreateFS map[string]*bytes.Buffer
+
+func (mfs memCreateFS) Mkdir(name string, mode fs.FileMode) (err error) {
+	return
+}
+
+func (mfs memCreateFS) Create(name string) (file io.WriteCloser, err error) {
+	buff := &bytes.Buffer{}
+	mfs[name] = buff
+	file = nopWriteCloser{buff}
+	return
+}
+
+func (mfs memCreateFS) Sub(name string) (sub CreateFS, err error) {
+	sub = mfs
+	return
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestDrumDebug(t *testing.T) {
+	assert := assert.New(t)
+
+	filesys := fstest.MapFS{
+		"00.ur":     &fstest.MapFile{Data: []byte{1, 2, 3, 4}},
+		"00.urd":    &fstest.MapFile{Data: []byte(`{"version":1}`)},
+		"01.ur":     &fstest.MapFile{Data: []byte{5, 6}},
+		"02.urd":    &fstest.MapFile{Data: []byte(`{"orphan":true}`)},
+		"00.ur.bak": &fstest.MapFile{Data: []byte{7}},
+	}
+
+	drum := &Drum{}
+	err := drum.Unmarshal(filesys)
+	assert.NoError(err)
+
+	assert.Equal(2, len(drum.Rings))
+	assert.Equal([]uint8{1, 2, 3, 4}, drum.Rings[0].Data)
+	assert.Equal([]uint8(`{"version":1}`), drum.Rings[0].Debug)
+	assert.Equal([]uint8{5, 6}, drum.Rings[1].Data)
+	assert.Nil(drum.Rings[1].Debug)
+
+	drum.Rings[0].isDirty = true
+	drum.Rings[1].Debug = []uint8(`{"version":2}`)
+	drum.Rings[1].isDirty = true
+
+	mfs := memCreateFS{}
+	err = drum.Marshal(mfs)
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2, 3, 4}, mfs["00.ur"].Bytes())
+	assert.Equal([]byte(`{"version":1}`), mfs["00.urd"].Bytes())
+	assert.Equal([]byte{5, 6}, mfs["01.ur"].Bytes())
+	assert.Equal([]byte(`{"version":2}`), mfs["01.urd"].Bytes())
+
+	// Saving new content drops stale debug info.
+	err = drum.Save("FOO", bytes.NewReader([]byte{8, 9}))
+	assert.NoError(err)
+	for dirent := range drum.Dirents() {
+		if dirent.NameIs("FOO") {
+			drum.Rings[dirent.Ring].Debug = []uint8(`{}`)
+		}
+	}
+	err = drum.Save("FOO", bytes.NewReader([]byte{10}))
+	assert.NoError(err)
+	for dirent := range drum.Dirents() {
+		if dirent.NameIs("FOO") {
+			assert.Equal([]uint8{10}, drum.Rings[dirent.Ring].Data)
+			assert.Nil(drum.Rings[dirent.Ring].Debug)
+		}
+	}
+}
@@ -22,3 +22,6 @@ var ErrNameTooShort = errors.New(f("name length too short"))
 
 // ErrNameTooLong is returned when the name is too long.
 var ErrNameTooLong = errors.New(f("name length too long"))
+
+// ErrStateInvalid is returned when a saved channel state is inconsistent.
+var ErrStateInvalid = errors.New(f("channel state invalid"))
@@ -39,6 +39,8 @@ type Ring struct {
 	ReadIndex  int
 	Data       []uint8
 
+	Debug []uint8 // Debug info of the program in the ring, if any.
+
 	isDirty bool
 }
 
@@ -0,0 +1,293 @@
+// Copyright 2025, Jason S. McMullan <jason.mcmullan@gmail.com>
+
+package sio
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// TemporaryState is the saved state of a Temporary buffer. The data bits
+// are packed LSB first.
+type TemporaryState struct {
+	ReadIndex  int    `json:"read"`  // Read position, in bits.
+	WriteIndex int    `json:"write"` // Write position, in bits.
+	Size       int    `json:"size"`  // Bits held by the buffer.
+	Data       []byte `json:"data"`  // Buffer content.
+}
+
+// SaveState returns the state of the Temporary buffer.
+func (temp *Temporary) SaveState() (state *TemporaryState) {
+	state = &TemporaryState{
+		ReadIndex:  temp.ReadIndex,
+		WriteIndex: temp.WriteIndex,
+		Size:       temp.Size,
+		Data:       make([]byte, (len(temp.Data)+7)/8),
+	}
+
+	for n, bit := range temp.Data {
+		if bit {
+			state.Data[n/8] |= 1 << (n % 8)
+		}
+	}
+
+	return
+}
+
+// LoadState restores the state of the Temporary buffer. The buffer must
+// have the same capacity as the saved buffer.
+func (temp *Temporary) LoadState(state *TemporaryState) (err error) {
+	if len(state.Data) != (temp.Capacity+7)/8 ||
+		state.ReadIndex < 0 || state.ReadIndex >= max(temp.Capacity, 1) ||
+		state.WriteIndex < 0 || state.WriteIndex >= max(temp.Capacity, 1) ||
+		state.Size < 0 || state.Size > temp.Capacity {
+		err = fmt.Errorf("%w: temporary", ErrStateInvalid)
+		return
+	}
+
+	temp.ReadIndex = state.ReadIndex
+	temp.WriteIndex = state.WriteIndex
+	temp.Size = state.Size
+	temp.Data = make([]bool, temp.Capacity)
+	for n := range temp.Data {
+		temp.Data[n] = state.Data[n/8]&(1<<(n%8)) != 0
+	}
+
+	return
+}
+
+// TapeState is the saved state of a Tape: the partial bytes of its input
+// and output.
+type TapeState struct {
+	ReadIndex  int   `json:"read"`        // Bits of LastInput already read.
+	HasInput   bool  `json:"has_input"`   // Set when LastInput has been read from the input.
+	LastInput  uint8 `json:"last_input"`  // Input byte being read.
+	NextOutput uint8 `json:"next_output"` // Output byte being written.
+	WriteIndex int   `json:"write"`       // Bits of NextOutput already written.
+}
+
+// SaveState returns the state of the Tape.
+func (tc *Tape) SaveState() (state *TapeState) {
+	state = &TapeState{
+		ReadIndex:  tc.readIndex,
+		HasInput:   tc.hasInput,
+		LastInput:  tc.lastInput,
+		NextOutput: tc.nextOutput,
+		WriteIndex: tc.writeIndex,
+	}
+	return
+}
+
+// LoadState restores the state of the Tape.
+func (tc *Tape) LoadState(state *TapeState) (err error) {
+	if state.ReadIndex < 0 || state.ReadIndex >= 8 || state.WriteIndex < 0 || state.WriteIndex >= 8 {
+		err = fmt.Errorf("%w: tape", ErrStateInvalid)
+		return
+	}
+
+	tc.readIndex = state.ReadIndex
+	tc.hasInput = state.HasInput
+	tc.lastInput = state.LastInput
+	tc.nextOutput = state.NextOutput
+	tc.writeIndex = state.WriteIndex
+	return
+}
+
+// RingState is the saved state of a Ring. The content of the ring is not
+// saved, as it is kept by the depot.
+type RingState struct {
+	ReadIndex  int `json:"read"`  // Read position, in bits.
+	WriteIndex int `json:"write"` // Write position, in bits.
+}
+
+// SaveState returns the state of the Ring.
+func (ring *Ring) SaveState() (state *RingState) {
+	state = &RingState{
+		ReadIndex:  ring.ReadIndex,
+		WriteIndex: ring.WriteIndex,
+	}
+	return
+}
+
+// LoadState restores the state of the Ring. The write position may not be
+// past the end of the ring's data.
+func (ring *Ring) LoadState(state *RingState) (err error) {
+	if state.ReadIndex < 0 || state.WriteIndex < 0 || state.WriteIndex > len(ring.Data)*8 {
+		err = fmt.Errorf("%w: ring", ErrStateInvalid)
+		return
+	}
+
+	ring.ReadIndex = state.ReadIndex
+	ring.WriteIndex = state.WriteIndex
+	return
+}
+
+// DrumState is the saved state of a Drum.
+type DrumState struct {
+	Ring  *uint8               `json:"ring,omitempty"` // Selected ring, if any.
+	Rings map[uint8]*RingState `json:"rings"`          // State of each ring.
+}
+
+// SaveState returns the state of the Drum.
+func (drum *Drum) SaveState() (state *DrumState) {
+	state = &DrumState{
+		Rings: make(map[uint8]*RingState, len(drum.Rings)),
+	}
+
+	for id, ring := range drum.Rings {
+		if ring == drum.Ring {
+			state.Ring = &id
+		}
+		state.Rings[id] = ring.SaveState()
+	}
+
+	return
+}
+
+// LoadState restores the state of the Drum. Rings missing from the drum
+// are created empty, as a ring selection would.
+func (drum *Drum) LoadState(state *DrumState) (err error) {
+	for _, id := range slices.Sorted(maps.Keys(state.Rings)) {
+		drum.selectRing(id)
+		err = drum.Ring.LoadState(state.Rings[id])
+		if err != nil {
+			err = fmt.Errorf("ring %02x: %w", id, err)
+			return
+		}
+	}
+
+	drum.Ring = nil
+	if state.Ring != nil {
+		drum.selectRing(*state.Ring)
+	}
+
+	return
+}
+
+// DepotState is the saved state of a Depot.
+type DepotState struct {
+	Drum  *uint32               `json:"drum,omitempty"` // Selected drum, if any.
+	Drums map[uint32]*DrumState `json:"drums"`          // State of each drum.
+}
+
+// SaveState returns the state of the Depot.
+func (depot *Depot) SaveState() (state *DepotState) {
+	state = &DepotState{
+		Drums: make(map[uint32]*DrumState, len(depot.Drums)),
+	}
+
+	for id, drum := range depot.Drums {
+		if drum == depot.Drum {
+			state.Drum = &id
+		}
+		state.Drums[id] = drum.SaveState()
+	}
+
+	return
+}
+
+// LoadState restores the state of the Depot. Every saved drum must be
+// in the depot.
+func (depot *Depot) LoadState(state *DepotState) (err error) {
+	for _, id := range slices.Sorted(maps.Keys(state.Drums)) {
+		drum, ok := depot.Drums[id]
+		if !ok {
+			err = fmt.Errorf("%w: %06x", ErrDrumMissing, id)
+			return
+		}
+		err = drum.LoadState(state.Drums[id])
+		if err != nil {
+			err = fmt.Errorf("drum %06x: %w", id, err)
+			return
+		}
+	}
+
+	depot.Drum = nil
+	if state.Drum != nil {
+		drum, ok := depot.Drums[*state.Drum]
+		if !ok {
+			err = fmt.Errorf("%w: %06x", ErrDrumMissing, *state.Drum)
+			return
+		}
+		depot.Drum = drum
+	}
+
+	return
+}
+
+// VtState is the saved state of a VirtualTerminal. Each cell of the frame
+// buffer is packed as its glyph, foreground color << 8, background color
+// << 12, bold << 16 and italic << 17.
+type VtState struct {
+	Row        int      `json:"row"`         // Row of the most recently written cell.
+	Column     int      `json:"column"`      // Column of the most recently written cell.
+	Frame      []uint32 `json:"frame"`       // Frame buffer, row by row.
+	Keys       []uint8  `json:"keys"`        // Key queue.
+	KeyIndex   int      `json:"key_index"`   // Bits of the first key already read.
+	WriteValue uint32   `json:"write_value"` // Store word being written.
+	WriteIndex int      `json:"write_index"` // Bits of the store word already written.
+}
+
+// SaveState returns the state of the VirtualTerminal.
+func (vt *VirtualTerminal) SaveState() (state *VtState) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	state = &VtState{
+		Row:        vt.Row,
+		Column:     vt.Column,
+		Frame:      make([]uint32, 0, VT_ROWS*VT_COLUMNS),
+		Keys:       slices.Clone(vt.keys),
+		KeyIndex:   vt.keyIndex,
+		WriteValue: vt.writeValue,
+		WriteIndex: vt.writeIndex,
+	}
+
+	for row := range vt.frame {
+		for _, cell := range vt.frame[row] {
+			word := uint32(cell.Glyph) | uint32(cell.Fg)<<8 | uint32(cell.Bg)<<12
+			if cell.Bold {
+				word |= 1 << 16
+			}
+			if cell.Italic {
+				word |= 1 << 17
+			}
+			state.Frame = append(state.Frame, word)
+		}
+	}
+
+	return
+}
+
+// LoadState restores the state of the VirtualTerminal.
+func (vt *VirtualTerminal) LoadState(state *VtState) (err error) {
+	if len(state.Frame) != VT_ROWS*VT_COLUMNS ||
+		state.Row < 0 || state.Row >= VT_ROWS || state.Column < 0 || state.Column >= VT_COLUMNS ||
+		state.KeyIndex < 0 || state.KeyIndex >= VT_FETCH_BITS ||
+		state.WriteIndex < 0 || state.WriteIndex >= VT_STORE_BITS {
+		err = fmt.Errorf("%w: vt", ErrStateInvalid)
+		return
+	}
+
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	vt.Row = state.Row
+	vt.Column = state.Column
+	for n, word := range state.Frame {
+		vt.frame[n/VT_COLUMNS][n%VT_COLUMNS] = VtCell{
+			Glyph:  uint8(word),
+			Fg:     uint8(word>>8) & 0xf,
+			Bg:     uint8(word>>12) & 0xf,
+			Bold:   word&(1<<16) != 0,
+			Italic: word&(1<<17) != 0,
+		}
+	}
+	vt.keys = slices.Clone(state.Keys)
+	vt.keyIndex = state.KeyIndex
+	vt.writeValue = state.WriteValue
+	vt.writeIndex = state.WriteIndex
+
+	return
+}
@@ -0,0 +1,144 @@
+package sio
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTrip encodes a state as JSON, and decodes it into restored.
+func roundTrip[T any](t *testing.T, state *T) (restored *T) {
+	data, err := json.Marshal(state)
+	assert.NoError(t, err)
+	restored = new(T)
+	assert.NoError(t, json.Unmarshal(data, restored))
+	return
+}
+
+func TestTemporary_State(t *testing.T) {
+	assert := assert.New(t)
+
+	temp := &Temporary{Capacity: 20}
+	temp.Rewind()
+	for _, bit := range []bool{true, false, true, true} {
+		assert.NoError(temp.Send(bit))
+	}
+	for range temp.Receive() {
+		break
+	}
+
+	restored := &Temporary{Capacity: 20}
+	restored.Rewind()
+	assert.NoError(restored.LoadState(roundTrip(t, temp.SaveState())))
+	assert.Equal(temp, restored)
+
+	small := &Temporary{Capacity: 8}
+	assert.ErrorIs(small.LoadState(temp.SaveState()), ErrStateInvalid)
+}
+
+func TestTape_State(t *testing.T) {
+	assert := assert.New(t)
+
+	var output bytes.Buffer
+	tape := &Tape{Input: bytes.NewReader([]byte{0xa5}), Output: &output}
+	n := 0
+	for range tape.Receive() {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	for range 5 {
+		assert.NoError(tape.Send(true))
+	}
+
+	restored := &Tape{Input: bytes.NewReader(nil), Output: &output}
+	assert.NoError(restored.LoadState(roundTrip(t, tape.SaveState())))
+
+	var bits []bool
+	for bit := range restored.Receive() {
+		bits = append(bits, bit)
+	}
+	assert.Equal([]bool{true, false, false, true, false, true}, bits)
+
+	for range 3 {
+		assert.NoError(restored.Send(false))
+	}
+	assert.Equal([]byte{0x1f}, output.Bytes())
+
+	assert.ErrorIs(restored.LoadState(&TapeState{ReadIndex: 8}), ErrStateInvalid)
+}
+
+func TestDepot_State(t *testing.T) {
+	assert := assert.New(t)
+
+	newDepot := func() *Depot {
+		depot := &Depot{Drums: map[uint32](*Drum){
+			1: {Rings: map[uint8](*Ring){0: {Data: []byte{1, 2, 3}}}},
+			2: {Rings: map[uint8](*Ring){0: {}, 5: {Data: []byte{4}}}},
+		}}
+		depot.Rewind()
+		return depot
+	}
+
+	depot := newDepot()
+	depot.Alert(DEPOT_OP_SELECT|2, make(chan uint32, 1))
+	depot.Alert(DEPOT_OP_DRUM|DRUM_OP_SELECT|5, make(chan uint32, 1))
+	for range depot.Receive() {
+		break
+	}
+	depot.Drums[1].Rings[0].ReadIndex = 9
+	depot.Drums[1].Rings[0].WriteIndex = 16
+
+	restored := newDepot()
+	assert.NoError(restored.LoadState(roundTrip(t, depot.SaveState())))
+	assert.Equal(restored.Drums[2], restored.Drum)
+	assert.Equal(restored.Drums[2].Rings[5], restored.Drum.Ring)
+	assert.Nil(restored.Drums[1].Ring)
+	assert.Equal(1, restored.Drum.Ring.ReadIndex)
+	assert.Equal(9, restored.Drums[1].Rings[0].ReadIndex)
+	assert.Equal(16, restored.Drums[1].Rings[0].WriteIndex)
+
+	state := depot.SaveState()
+	state.Drums[1].Rings[0].WriteIndex = 32
+	assert.ErrorIs(newDepot().LoadState(state), ErrStateInvalid)
+
+	state = depot.SaveState()
+	state.Drums[3] = &DrumState{}
+	assert.ErrorIs(newDepot().LoadState(state), ErrDrumMissing)
+}
+
+func TestVirtualTerminal_State(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+	store := func(word uint32, bits int) {
+		for n := range bits {
+			assert.NoError(vt.Send((word>>n)&1 != 0))
+		}
+	}
+	store(3<<VT_ROW_SHIFT|4<<VT_COLUMN_SHIFT|'A', VT_STORE_BITS)
+	store(3<<VT_ROW_SHIFT|4<<VT_COLUMN_SHIFT|VT_ATTRIBUTE|VT_BOLD|2<<VT_BG_SHIFT|5, VT_STORE_BITS)
+	store(1<<VT_ROW_SHIFT|'B', 10)
+	vt.PushKey('x', 'y')
+
+	restored := &VirtualTerminal{}
+	assert.NoError(restored.LoadState(roundTrip(t, vt.SaveState())))
+	assert.Equal(VtCell{Glyph: 'A', Fg: 5, Bg: 2, Bold: true}, restored.Cell(3, 4))
+	assert.Equal(3, restored.Row)
+	assert.Equal(4, restored.Column)
+	assert.Equal(2, restored.Pending())
+
+	store = func(word uint32, bits int) {
+		for n := range bits {
+			assert.NoError(restored.Send((word>>n)&1 != 0))
+		}
+	}
+	store((1<<VT_ROW_SHIFT|'B')>>10, VT_STORE_BITS-10)
+	assert.Equal(uint8('B'), restored.Cell(1, 0).Glyph)
+
+	assert.ErrorIs(restored.LoadState(&VtState{}), ErrStateInvalid)
+}
@@ -0,0 +1,283 @@
+package sio
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+)
+
+const (
+	// VT_COLUMNS is the number of columns in the VT frame buffer.
+	VT_COLUMNS = 128
+	// VT_ROWS is the number of rows in the VT frame buffer.
+	VT_ROWS = 64
+
+	// VT_STORE_BITS is the number of bits in a single VT store word.
+	VT_STORE_BITS = 24
+	// VT_FETCH_BITS is the number of bits in a single VT keycode.
+	VT_FETCH_BITS = 8
+
+	// VT_GLYPH_MASK masks the glyph value from a cell content write.
+	VT_GLYPH_MASK = 0xff
+	// VT_FG_MASK masks the foreground color from a cell attribute write.
+	VT_FG_MASK = 0xf
+	// VT_BG_SHIFT is the bit position of the background color.
+	VT_BG_SHIFT = 4
+	// VT_BG_MASK masks the background color from a cell attribute write.
+	VT_BG_MASK = (0xf << VT_BG_SHIFT)
+	// VT_BOLD is set in a cell attribute write to make the cell bold.
+	VT_BOLD = (1 << 8)
+	// VT_ITALIC is set in a cell attribute write to make the cell italic.
+	VT_ITALIC = (1 << 9)
+	// VT_ATTRIBUTE is set to indicate a cell attribute write.
+	VT_ATTRIBUTE = (1 << 10)
+	// VT_ROW_SHIFT is the bit position of the row number.
+	VT_ROW_SHIFT = 11
+	// VT_ROW_MASK masks the row number from a store word.
+	VT_ROW_MASK = (0x3f << VT_ROW_SHIFT)
+	// VT_COLUMN_SHIFT is the bit position of the column number.
+	VT_COLUMN_SHIFT = 17
+	// VT_COLUMN_MASK masks the column number from a store word.
+	VT_COLUMN_MASK = (0x7f << VT_COLUMN_SHIFT)
+
+	// VT_FG_DEFAULT is the foreground color of a cleared cell.
+	VT_FG_DEFAULT = 7
+	// VT_BG_DEFAULT is the background color of a cleared cell.
+	VT_BG_DEFAULT = 0
+
+	// VT_OP_CLEAR clears the frame buffer.
+	VT_OP_CLEAR = 0
+	// VT_OP_PENDING responds with the number of keycodes in the key queue.
+	VT_OP_PENDING = 1
+)
+
+// VT keycodes, as documented in KEYMAP.md.
+// Printable ASCII keys, and the control keys below 0x20, are their own keycode.
+const (
+	VT_KEY_BACKSPACE = 0x08
+	VT_KEY_TAB       = 0x09
+	VT_KEY_ENTER     = 0x0d
+	VT_KEY_ESCAPE    = 0x1b
+	VT_KEY_DELETE    = 0x7f
+	VT_KEY_UP        = 0x80
+	VT_KEY_DOWN      = 0x81
+	VT_KEY_LEFT      = 0x82
+	VT_KEY_RIGHT     = 0x83
+	VT_KEY_HOME      = 0x84
+	VT_KEY_END       = 0x85
+	VT_KEY_PAGE_UP   = 0x86
+	VT_KEY_PAGE_DOWN = 0x87
+	VT_KEY_INSERT    = 0x88
+	VT_KEY_F1        = 0x91 // F1..F12 are 0x91..0x9c
+)
+
+var _vt_defines = map[string]string{
+	"VT_COLUMNS":      fmt.Sprintf("%d", VT_COLUMNS),
+	"VT_ROWS":         fmt.Sprintf("%d", VT_ROWS),
+	"VT_GLYPH_MASK":   fmt.Sprintf("0x%x", VT_GLYPH_MASK),
+	"VT_FG_MASK":      fmt.Sprintf("0x%x", VT_FG_MASK),
+	"VT_BG_SHIFT":     fmt.Sprintf("%d", VT_BG_SHIFT),
+	"VT_BG_MASK":      fmt.Sprintf("0x%x", VT_BG_MASK),
+	"VT_BOLD":         fmt.Sprintf("0x%x", VT_BOLD),
+	"VT_ITALIC":       fmt.Sprintf("0x%x", VT_ITALIC),
+	"VT_ATTRIBUTE":    fmt.Sprintf("0x%x", VT_ATTRIBUTE),
+	"VT_ROW_SHIFT":    fmt.Sprintf("%d", VT_ROW_SHIFT),
+	"VT_ROW_MASK":     fmt.Sprintf("0x%x", VT_ROW_MASK),
+	"VT_COLUMN_SHIFT": fmt.Sprintf("%d", VT_COLUMN_SHIFT),
+	"VT_COLUMN_MASK":  fmt.Sprintf("0x%x", VT_COLUMN_MASK),
+	"VT_OP_CLEAR":     fmt.Sprintf("0x%x", VT_OP_CLEAR),
+	"VT_OP_PENDING":   fmt.Sprintf("0x%x", VT_OP_PENDING),
+
+	"VT_KEY_BACKSPACE": fmt.Sprintf("0x%x", VT_KEY_BACKSPACE),
+	"VT_KEY_TAB":       fmt.Sprintf("0x%x", VT_KEY_TAB),
+	"VT_KEY_ENTER":     fmt.Sprintf("0x%x", VT_KEY_ENTER),
+	"VT_KEY_ESCAPE":    fmt.Sprintf("0x%x", VT_KEY_ESCAPE),
+	"VT_KEY_DELETE":    fmt.Sprintf("0x%x", VT_KEY_DELETE),
+	"VT_KEY_UP":        fmt.Sprintf("0x%x", VT_KEY_UP),
+	"VT_KEY_DOWN":      fmt.Sprintf("0x%x", VT_KEY_DOWN),
+	"VT_KEY_LEFT":      fmt.Sprintf("0x%x", VT_KEY_LEFT),
+	"VT_KEY_RIGHT":     fmt.Sprintf("0x%x", VT_KEY_RIGHT),
+	"VT_KEY_HOME":      fmt.Sprintf("0x%x", VT_KEY_HOME),
+	"VT_KEY_END":       fmt.Sprintf("0x%x", VT_KEY_END),
+	"VT_KEY_PAGE_UP":   fmt.Sprintf("0x%x", VT_KEY_PAGE_UP),
+	"VT_KEY_PAGE_DOWN": fmt.Sprintf("0x%x", VT_KEY_PAGE_DOWN),
+	"VT_KEY_INSERT":    fmt.Sprintf("0x%x", VT_KEY_INSERT),
+	"VT_KEY_F1":        fmt.Sprintf("0x%x", VT_KEY_F1),
+}
+
+// VtCell is a single character cell of the VT frame buffer.
+type VtCell struct {
+	Glyph  uint8 // Glyph value.
+	Fg     uint8 // Foreground color (0..15).
+	Bg     uint8 // Background color (0..15).
+	Bold   bool  // Bold text.
+	Italic bool  // Italic text.
+}
+
+// Rune returns the printable rune for the cell's glyph.
+// Control glyphs are shown as '.', and glyphs 0x80..0xff are Latin-1.
+func (cell VtCell) Rune() rune {
+	switch {
+	case cell.Glyph < 0x20, cell.Glyph == 0x7f:
+		return '.'
+	default:
+		return rune(cell.Glyph)
+	}
+}
+
+// VirtualTerminal is a full screen terminal with a 128x64 frame buffer
+// and a key queue. Stores of 24-bit words update the frame buffer, and
+// fetches yield 8-bit keycodes from the key queue, LSB first.
+type VirtualTerminal struct {
+	Row    int // Row of the most recently written cell.
+	Column int // Column of the most recently written cell.
+
+	frame [VT_ROWS][VT_COLUMNS]VtCell
+
+	keys     []uint8
+	keyIndex int
+
+	writeValue uint32
+	writeIndex int
+
+	mutex sync.Mutex
+}
+
+var _ Channel = (*VirtualTerminal)(nil)
+
+// Defines returns an iter of defines for the channel.
+func (vt *VirtualTerminal) Defines() iter.Seq2[string, string] {
+	return maps.All(_vt_defines)
+}
+
+// Rewind clears the frame buffer and any partially stored word.
+// Pending keycodes in the key queue are preserved.
+func (vt *VirtualTerminal) Rewind() {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	vt.clear()
+	vt.writeValue = 0
+	vt.writeIndex = 0
+	vt.keyIndex = 0
+}
+
+// clear resets all cells of the frame buffer.
+func (vt *VirtualTerminal) clear() {
+	for row := range vt.frame {
+		for col := range vt.frame[row] {
+			vt.frame[row][col] = VtCell{Glyph: ' ', Fg: VT_FG_DEFAULT, Bg: VT_BG_DEFAULT}
+		}
+	}
+	vt.Row = 0
+	vt.Column = 0
+}
+
+// Cell returns the content of the frame buffer at a row and column.
+func (vt *VirtualTerminal) Cell(row, column int) (cell VtCell) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	cell = vt.frame[row%VT_ROWS][column%VT_COLUMNS]
+	return
+}
+
+// PushKey appends keycodes to the key queue.
+func (vt *VirtualTerminal) PushKey(keys ...uint8) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	vt.keys = append(vt.keys, keys...)
+}
+
+// Pending returns the number of keycodes in the key queue.
+func (vt *VirtualTerminal) Pending() int {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	return len(vt.keys)
+}
+
+// Receive returns an iterator that yields bits from the key queue,
+// each keycode LSB first, until the queue is empty.
+func (vt *VirtualTerminal) Receive() iter.Seq[bool] {
+	return func(yield func(value bool) bool) {
+		for {
+			vt.mutex.Lock()
+			if len(vt.keys) == 0 {
+				vt.mutex.Unlock()
+				return
+			}
+			bit := ((vt.keys[0] >> vt.keyIndex) & 1) != 0
+			vt.mutex.Unlock()
+
+			if !yield(bit) {
+				return
+			}
+
+			vt.mutex.Lock()
+			vt.keyIndex++
+			if vt.keyIndex == VT_FETCH_BITS {
+				vt.keyIndex = 0
+				vt.keys = vt.keys[1:]
+			}
+			vt.mutex.Unlock()
+		}
+	}
+}
+
+// Send collects a bit of a 24-bit store word, LSB first, and updates
+// the frame buffer once the word is complete.
+func (vt *VirtualTerminal) Send(value bool) (err error) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	if value {
+		vt.writeValue |= 1 << vt.writeIndex
+	}
+
+	vt.writeIndex++
+
+	if vt.writeIndex == VT_STORE_BITS {
+		vt.store(vt.writeValue)
+		vt.writeValue = 0
+		vt.writeIndex = 0
+	}
+
+	return
+}
+
+// store updates the frame buffer with a single store word.
+func (vt *VirtualTerminal) store(word uint32) {
+	row := int((word & VT_ROW_MASK) >> VT_ROW_SHIFT)
+	col := int((word & VT_COLUMN_MASK) >> VT_COLUMN_SHIFT)
+
+	cell := &vt.frame[row][col]
+	if (word & VT_ATTRIBUTE) == 0 {
+		cell.Glyph = uint8(word & VT_GLYPH_MASK)
+	} else {
+		cell.Fg = uint8(word & VT_FG_MASK)
+		cell.Bg = uint8((word & VT_BG_MASK) >> VT_BG_SHIFT)
+		cell.Bold = (word & VT_BOLD) != 0
+		cell.Italic = (word & VT_ITALIC) != 0
+	}
+
+	vt.Row = row
+	vt.Column = col
+}
+
+// Alert handles VT control operations.
+func (vt *VirtualTerminal) Alert(request uint32, response chan uint32) {
+	switch request {
+	case VT_OP_CLEAR:
+		vt.mutex.Lock()
+		vt.clear()
+		vt.mutex.Unlock()
+		response <- 0
+	case VT_OP_PENDING:
+		response <- uint32(vt.Pending())
+	default:
+		response <- ^uint32(0)
+	}
+}
@@ -0,0 +1,158 @@
+package sio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func vtSend(vt *VirtualTerminal, word uint32) {
+	for n := range VT_STORE_BITS {
+		vt.Send(((word >> n) & 1) == 1)
+	}
+}
+
+func TestVirtualTerminal_Rewind(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	for row := range VT_ROWS {
+		for col := range VT_COLUMNS {
+			assert.Equal(VtCell{Glyph: ' ', Fg: VT_FG_DEFAULT, Bg: VT_BG_DEFAULT}, vt.Cell(row, col))
+		}
+	}
+
+	vt.PushKey('a')
+	vtSend(vt, (5<<VT_COLUMN_SHIFT)|(6<<VT_ROW_SHIFT)|'x')
+	vt.Send(true)
+	vt.Rewind()
+
+	assert.Equal(uint8(' '), vt.Cell(6, 5).Glyph)
+	assert.Equal(1, vt.Pending())
+
+	// Partial word must have been discarded.
+	vtSend(vt, (5<<VT_COLUMN_SHIFT)|(6<<VT_ROW_SHIFT)|'y')
+	assert.Equal(uint8('y'), vt.Cell(6, 5).Glyph)
+}
+
+func TestVirtualTerminal_Send(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	vtSend(vt, (127<<VT_COLUMN_SHIFT)|(63<<VT_ROW_SHIFT)|'Z')
+	assert.Equal(63, vt.Row)
+	assert.Equal(127, vt.Column)
+
+	vtSend(vt, (127<<VT_COLUMN_SHIFT)|(63<<VT_ROW_SHIFT)|VT_ATTRIBUTE|VT_ITALIC|(0xc<<VT_BG_SHIFT)|0x3)
+
+	cell := vt.Cell(63, 127)
+	assert.Equal(VtCell{Glyph: 'Z', Fg: 0x3, Bg: 0xc, Italic: true}, cell)
+
+	// Glyph writes leave the attributes alone.
+	vtSend(vt, (127<<VT_COLUMN_SHIFT)|(63<<VT_ROW_SHIFT)|'!')
+	cell = vt.Cell(63, 127)
+	assert.Equal(VtCell{Glyph: '!', Fg: 0x3, Bg: 0xc, Italic: true}, cell)
+}
+
+func TestVirtualTerminal_Receive(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	count := 0
+	for range vt.Receive() {
+		count++
+	}
+	assert.Equal(0, count)
+
+	vt.PushKey(0x55, VT_KEY_UP)
+
+	var bits []bool
+	for bit := range vt.Receive() {
+		bits = append(bits, bit)
+		if len(bits) == 4 {
+			break
+		}
+	}
+	assert.Equal([]bool{true, false, true, false}, bits)
+	assert.Equal(2, vt.Pending())
+
+	// The interrupted read continues from the bit it was stopped at.
+	bits = bits[:0]
+	for bit := range vt.Receive() {
+		bits = append(bits, bit)
+	}
+	assert.Len(bits, 13)
+	assert.Equal([]bool{false, true, false, true, false}, bits[0:5])
+	assert.Equal([]bool{false, false, false, false, false, false, false, true}, bits[5:13])
+	assert.Equal(0, vt.Pending())
+}
+
+func TestVirtualTerminal_Alert(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	response := make(chan uint32, 1)
+	defer close(response)
+
+	vt.PushKey('a', 'b', 'c')
+	vt.Alert(VT_OP_PENDING, response)
+	assert.Equal(uint32(3), <-response)
+
+	vtSend(vt, (1<<VT_COLUMN_SHIFT)|(1<<VT_ROW_SHIFT)|'q')
+	vt.Alert(VT_OP_CLEAR, response)
+	assert.Equal(uint32(0), <-response)
+	assert.Equal(uint8(' '), vt.Cell(1, 1).Glyph)
+
+	vt.Alert(0x1234, response)
+	assert.Equal(^uint32(0), <-response)
+}
+
+func TestVirtualTerminal_TypeString(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	vt.TypeString("ls\n")
+
+	var keys []uint8
+	for key := range ReceiveAsUint8(vt) {
+		keys = append(keys, key)
+	}
+	assert.Equal([]uint8{'l', 's', VT_KEY_ENTER}, keys)
+}
+
+func TestVirtualTerminal_Text(t *testing.T) {
+	assert := assert.New(t)
+
+	vt := &VirtualTerminal{}
+	vt.Rewind()
+
+	assert.Equal("", vt.Text())
+	assert.Equal("", vt.Annotated())
+
+	vtSend(vt, (1<<VT_COLUMN_SHIFT)|(0<<VT_ROW_SHIFT)|'A')
+	vtSend(vt, (2<<VT_COLUMN_SHIFT)|(2<<VT_ROW_SHIFT)|0x01)
+	vtSend(vt, (3<<VT_COLUMN_SHIFT)|(2<<VT_ROW_SHIFT)|0xe9)
+
+	assert.Equal(" A\n\n  .é\n", vt.Text())
+	assert.Equal(vt.Text(), vt.Annotated())
+
+	vtSend(vt, (2<<VT_COLUMN_SHIFT)|(2<<VT_ROW_SHIFT)|VT_ATTRIBUTE|VT_BOLD|(1<<VT_BG_SHIFT)|2)
+	vtSend(vt, (3<<VT_COLUMN_SHIFT)|(2<<VT_ROW_SHIFT)|VT_ATTRIBUTE|VT_BOLD|(1<<VT_BG_SHIFT)|2)
+	vtSend(vt, (127<<VT_COLUMN_SHIFT)|(63<<VT_ROW_SHIFT)|VT_ATTRIBUTE|VT_ITALIC|VT_FG_DEFAULT)
+
+	expected := " A\n\n  .é\n" +
+		"--\n" +
+		"@2,2-3 fg=2 bg=1 bold\n" +
+		"@63,127-127 fg=7 bg=0 italic\n"
+	assert.Equal(expected, vt.Annotated())
+}
@@ -0,0 +1,101 @@
+package sio
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeString appends the keycodes for a string of text to the key queue,
+// as if it had been typed on the VT keyboard. Newlines are sent as
+// VT_KEY_ENTER, and all other bytes are sent as their own keycode.
+func (vt *VirtualTerminal) TypeString(text string) {
+	keys := make([]uint8, 0, len(text))
+	for _, key := range []byte(text) {
+		if key == '\n' {
+			key = VT_KEY_ENTER
+		}
+		keys = append(keys, key)
+	}
+
+	vt.PushKey(keys...)
+}
+
+// Frame returns a copy of the frame buffer.
+func (vt *VirtualTerminal) Frame() (frame [VT_ROWS][VT_COLUMNS]VtCell) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	frame = vt.frame
+	return
+}
+
+// Text renders the frame buffer as plain text, one line per row.
+// Trailing spaces of each row, and trailing blank rows, are omitted.
+func (vt *VirtualTerminal) Text() string {
+	frame := vt.Frame()
+
+	var lines []string
+	for _, row := range frame {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteRune(cell.Rune())
+		}
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+	}
+
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var text string
+	for _, line := range lines {
+		text += line + "\n"
+	}
+
+	return text
+}
+
+// Annotated renders the frame buffer as plain text (see Text), followed
+// by a "--" separator line and a list of every run of cells in a row
+// that have non-default attributes, in the form:
+//
+//	@ROW,COL-COL fg=N bg=N [bold] [italic]
+//
+// The separator and list are omitted if all cells have default attributes.
+func (vt *VirtualTerminal) Annotated() string {
+	frame := vt.Frame()
+
+	text := vt.Text()
+	separator := "--\n"
+
+	plain := VtCell{Fg: VT_FG_DEFAULT, Bg: VT_BG_DEFAULT}
+	attrs := func(cell VtCell) VtCell {
+		cell.Glyph = 0
+		return cell
+	}
+
+	for row := range frame {
+		for col := 0; col < VT_COLUMNS; {
+			attr := attrs(frame[row][col])
+			end := col + 1
+			for end < VT_COLUMNS && attrs(frame[row][end]) == attr {
+				end++
+			}
+			if attr != plain {
+				text += separator
+				separator = ""
+				text += fmt.Sprintf("@%d,%d-%d fg=%d bg=%d", row, col, end-1, attr.Fg, attr.Bg)
+				if attr.Bold {
+					text += " bold"
+				}
+				if attr.Italic {
+					text += " italic"
+				}
+				text += "\n"
+			}
+			col = end
+		}
+	}
+
+	return text
+}